@@ -0,0 +1,161 @@
+package cloudwatch
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/pkg/errors"
+)
+
+// ErrBufferFull is returned by Write when the writer's buffer is full and the
+// writer was created with FailWhenFull.
+var ErrBufferFull = errors.New("the writer's buffer is full")
+
+// ErrWriteTooLarge is returned by Write when the data is larger than the byte
+// limit of the writer's buffer, which it could never fit in, and the writer was
+// created with BlockWhenFull or FailWhenFull.
+var ErrWriteTooLarge = errors.New("the data is larger than the writer's buffer")
+
+type bufferPolicy int
+
+const (
+	blockWhenFull bufferPolicy = iota
+	dropNewestWhenFull
+	dropOldestWhenFull
+	failWhenFull
+)
+
+// WithBufferLimit limits the events held by the writer until they're sent to
+// at most maxBytes, counting the per-event overhead CloudWatch Logs does, and
+// at most maxEvents. Zero means no limit. What happens once the buffer is full
+// depends on the policy, which is BlockWhenFull unless set otherwise.
+func WithBufferLimit(maxBytes, maxEvents int) CreateOption {
+	return func(w *writerImpl) {
+		w.maxBufferBytes = maxBytes
+		w.maxBufferEvents = maxEvents
+	}
+}
+
+// BlockWhenFull makes Write block while the data doesn't fit in the writer's
+// buffer, until events are sent, the writer is closed or fails, or its context
+// is done. This is the default.
+func BlockWhenFull() CreateOption {
+	return func(w *writerImpl) {
+		w.bufferPolicy = blockWhenFull
+	}
+}
+
+// DropNewestWhenFull makes the writer drop events which don't fit in its
+// buffer.
+func DropNewestWhenFull() CreateOption {
+	return func(w *writerImpl) {
+		w.bufferPolicy = dropNewestWhenFull
+	}
+}
+
+// DropOldestWhenFull makes the writer drop the earliest buffered events to
+// make room for new ones.
+func DropOldestWhenFull() CreateOption {
+	return func(w *writerImpl) {
+		w.bufferPolicy = dropOldestWhenFull
+	}
+}
+
+// FailWhenFull makes Write return ErrBufferFull without buffering anything
+// when the data doesn't fit in the writer's buffer.
+func FailWhenFull() CreateOption {
+	return func(w *writerImpl) {
+		w.bufferPolicy = failWhenFull
+	}
+}
+
+// waitForSpace applies the blocking and failing policies before data of the
+// given size is buffered by Write. The data results in at least one event, so
+// the overhead of one is counted as well.
+func (w *writerImpl) waitForSpace(size int) error {
+	if w.bufferPolicy != blockWhenFull && w.bufferPolicy != failWhenFull {
+		return nil
+	}
+
+	size += paddingSize
+	if w.maxBufferBytes > 0 && size > w.maxBufferBytes {
+		return ErrWriteTooLarge
+	}
+
+	waited := false
+	for !w.fits(size, 1) {
+		if w.bufferPolicy == failWhenFull {
+			return ErrBufferFull
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		case <-w.space:
+			waited = true
+		}
+
+		if w.isClosed() {
+			return io.ErrClosedPipe
+		} else if err := w.error(); err != nil {
+			return err
+		}
+	}
+
+	// Other calls to Write may be waiting as well.
+	if waited {
+		w.signalSpace()
+	}
+	return nil
+}
+
+// enqueue adds the event to the buffer and the spool, applying the dropping
+// policies. Failing to write to the spool is reported by buffer.
+func (w *writerImpl) enqueue(event *cloudwatchlogs.InputLogEvent) {
+	if event.Message == nil {
+		return
+	}
+	size := len(*event.Message) + paddingSize
+
+	switch w.bufferPolicy {
+	case dropNewestWhenFull:
+		if !w.fits(size, 1) {
+			atomic.AddInt64(&w.stats.Dropped, 1)
+			return
+		}
+	case dropOldestWhenFull:
+		for !w.fits(size, 1) {
+			dropped := w.events.dropOldest()
+			if dropped == nil {
+				break
+			}
+			w.spool.ack(dropped)
+			atomic.AddInt64(&w.stats.Dropped, 1)
+		}
+		if !w.fits(size, 1) {
+			atomic.AddInt64(&w.stats.Dropped, 1)
+			return
+		}
+	}
+
+	w.spool.append(event)
+	w.events.add(event)
+}
+
+// fits checks whether the given number of bytes and events can be added to
+// the buffer without exceeding its limits.
+func (w *writerImpl) fits(bytes, events int) bool {
+	count, size := w.events.len()
+
+	return (w.maxBufferEvents <= 0 || count+events <= w.maxBufferEvents) &&
+		(w.maxBufferBytes <= 0 || size+bytes <= w.maxBufferBytes)
+}
+
+// signalSpace wakes up a blocked Write, if any.
+func (w *writerImpl) signalSpace() {
+	select {
+	case w.space <- struct{}{}:
+	default:
+	}
+}
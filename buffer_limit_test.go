@@ -0,0 +1,181 @@
+package cloudwatch
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type bufferLimitTestSuite struct {
+	suite.Suite
+
+	sut *writerImpl
+}
+
+func (b *bufferLimitTestSuite) SetupTest() {
+	b.sut = &writerImpl{
+		ctx:    context.Background(),
+		events: newEventsBuffer(),
+		space:  make(chan struct{}, 1),
+	}
+	WithBufferLimit(0, 2)(b.sut)
+}
+
+func (b *bufferLimitTestSuite) TestDropNewestWhenFull() {
+	DropNewestWhenFull()(b.sut)
+
+	b.write("a\nb\nc\n")
+
+	b.Equal([]string{"a\n", "b\n"}, b.drain())
+	b.EqualValues(1, b.sut.Stats().Dropped)
+}
+
+func (b *bufferLimitTestSuite) TestDropOldestWhenFull() {
+	DropOldestWhenFull()(b.sut)
+
+	b.write("a\nb\nc\nd\n")
+
+	b.Equal([]string{"c\n", "d\n"}, b.drain())
+	b.EqualValues(2, b.sut.Stats().Dropped)
+}
+
+func (b *bufferLimitTestSuite) TestDropsEventsLargerThanLimit() {
+	WithBufferLimit(paddingSize+1, 0)(b.sut)
+	DropOldestWhenFull()(b.sut)
+
+	b.write("a")
+	b.write("bc")
+
+	b.Empty(b.drain())
+	b.EqualValues(2, b.sut.Stats().Dropped)
+}
+
+func (b *bufferLimitTestSuite) TestFailWhenFull() {
+	FailWhenFull()(b.sut)
+
+	b.write("a\nb\n")
+
+	_, err := io.WriteString(b.sut, "c\n")
+	b.Equal(ErrBufferFull, err)
+
+	b.Equal([]string{"a\n", "b\n"}, b.drain())
+	b.write("c\n")
+}
+
+func (b *bufferLimitTestSuite) TestBlockWhenFull() {
+	b.write("a\nb\n")
+
+	done := make(chan error)
+	go func() {
+		_, err := io.WriteString(b.sut, "c\n")
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		b.Fail("Write should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.Equal([]string{"a\n", "b\n"}, b.drain())
+	b.sut.signalSpace()
+
+	b.NoError(<-done)
+	b.Equal([]string{"c\n"}, b.drain())
+}
+
+func (b *bufferLimitTestSuite) TestBlockUntilWriteFits() {
+	WithBufferLimit(2*paddingSize+4, 0)(b.sut)
+	b.write("a\n")
+
+	// The buffer isn't full, but the data doesn't fit.
+	done := make(chan error)
+	go func() {
+		_, err := io.WriteString(b.sut, "bcd\n")
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		b.Fail("Write should block while the data doesn't fit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	b.Equal([]string{"a\n"}, b.drain())
+	b.sut.signalSpace()
+
+	b.NoError(<-done)
+	b.Equal([]string{"bcd\n"}, b.drain())
+}
+
+func (b *bufferLimitTestSuite) TestFailUnlessWriteFits() {
+	WithBufferLimit(2*paddingSize+4, 0)(b.sut)
+	FailWhenFull()(b.sut)
+	b.write("a\n")
+
+	_, err := io.WriteString(b.sut, "bcd\n")
+	b.Equal(ErrBufferFull, err)
+
+	b.Equal([]string{"a\n"}, b.drain())
+	b.write("bcd\n")
+}
+
+func (b *bufferLimitTestSuite) TestWriteLargerThanLimit() {
+	WithBufferLimit(paddingSize+2, 0)(b.sut)
+
+	for _, policy := range []CreateOption{BlockWhenFull(), FailWhenFull()} {
+		policy(b.sut)
+
+		n, err := io.WriteString(b.sut, "abc")
+		b.Zero(n)
+		b.Equal(ErrWriteTooLarge, err)
+		b.Empty(b.drain())
+	}
+}
+
+func (b *bufferLimitTestSuite) TestBlockWhenFull_Closed() {
+	b.write("a\nb\n")
+
+	done := make(chan error)
+	go func() {
+		_, err := io.WriteString(b.sut, "c\n")
+		done <- err
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	atomic.StoreInt32(&b.sut.closed, 1)
+	b.sut.signalSpace()
+
+	b.Equal(io.ErrClosedPipe, <-done)
+}
+
+func (b *bufferLimitTestSuite) TestBlockWhenFull_ContextDone() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.sut.ctx = ctx
+	b.write("a\nb\n")
+
+	cancel()
+	_, err := io.WriteString(b.sut, "c\n")
+	b.Equal(context.Canceled, err)
+}
+
+func (b *bufferLimitTestSuite) write(data string) {
+	_, err := io.WriteString(b.sut, data)
+	b.Require().NoError(err)
+}
+
+func (b *bufferLimitTestSuite) drain() []string {
+	var ret []string
+	for _, event := range b.sut.events.drain() {
+		ret = append(ret, *event.Message)
+	}
+	return ret
+}
+
+func TestBufferLimit(t *testing.T) {
+	suite.Run(t, new(bufferLimitTestSuite))
+}
@@ -0,0 +1,97 @@
+package cloudwatch
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+// Checkpoint records how far a reader got in a log stream, so that another
+// reader can resume from there using FromCheckpoint. It can be serialized to
+// JSON.
+type Checkpoint struct {
+	// Token is the forward token of the page holding the next event to read.
+	// It is empty if that's the first page.
+	Token string `json:"token,omitempty"`
+
+	// Skip is the number of events on that page which were already read.
+	Skip int `json:"skip,omitempty"`
+
+	// Timestamp of the last event read.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// CheckpointStore persists reader checkpoints, so that readers can survive
+// process restarts.
+type CheckpointStore interface {
+	// Load returns the checkpoint saved for the stream, or nil if there is
+	// none.
+	Load(ctx context.Context, groupName, streamName string) (*Checkpoint, error)
+
+	// Save records the checkpoint for the stream.
+	Save(ctx context.Context, groupName, streamName string, checkpoint Checkpoint) error
+}
+
+// WithCheckpointStore makes the reader resume from the checkpoint saved in the
+// store, if there is one, and save its checkpoint every interval and when it's
+// closed. A saved checkpoint takes precedence over FromCheckpoint.
+func WithCheckpointStore(store CheckpointStore, interval time.Duration) OpenOption {
+	return func(r *readerImpl) {
+		r.store = store
+		r.saveInterval = interval
+	}
+}
+
+// FromCheckpoint makes the reader resume from a checkpoint previously
+// returned by a reader of the same stream opened with the same options.
+func FromCheckpoint(checkpoint Checkpoint) OpenOption {
+	return func(r *readerImpl) {
+		if checkpoint.Token != "" {
+			r.nextToken = aws.String(checkpoint.Token)
+		}
+		r.skip = checkpoint.Skip
+
+		// The checkpoint may be loaded from a store while the reader is used.
+		r.queue.setPosition(checkpoint)
+	}
+}
+
+func (r *readerImpl) loadCheckpoint() error {
+	if r.store == nil {
+		return nil
+	}
+
+	checkpoint, err := r.store.Load(r.ctx, *r.groupName, *r.streamName)
+	if err != nil {
+		return errors.Wrap(err, "could not load the checkpoint")
+	}
+
+	if checkpoint != nil {
+		FromCheckpoint(*checkpoint)(r)
+		r.lastSaved = *checkpoint
+	}
+
+	r.lastSavedAt = time.Now()
+	return nil
+}
+
+func (r *readerImpl) saveCheckpoint(ctx context.Context) error {
+	if r.store == nil {
+		return nil
+	}
+
+	checkpoint := r.Checkpoint()
+	if checkpoint.Token == r.lastSaved.Token && checkpoint.Skip == r.lastSaved.Skip {
+		return nil
+	}
+
+	if err := r.store.Save(ctx, *r.groupName, *r.streamName, checkpoint); err != nil {
+		return errors.Wrap(err, "could not save the checkpoint")
+	}
+
+	r.lastSaved = checkpoint
+	r.lastSavedAt = time.Now()
+	return nil
+}
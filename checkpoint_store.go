@@ -0,0 +1,137 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// checkpoints maps group names to stream names to checkpoints.
+type checkpoints map[string]map[string]Checkpoint
+
+func (c checkpoints) get(groupName, streamName string) *Checkpoint {
+	checkpoint, exists := c[groupName][streamName]
+	if !exists {
+		return nil
+	}
+	return &checkpoint
+}
+
+func (c checkpoints) set(groupName, streamName string, checkpoint Checkpoint) {
+	if c[groupName] == nil {
+		c[groupName] = make(map[string]Checkpoint)
+	}
+	c[groupName][streamName] = checkpoint
+}
+
+type memoryCheckpointStore struct {
+	checkpoints checkpoints
+	sync.Mutex
+}
+
+// NewMemoryCheckpointStore returns a CheckpointStore keeping checkpoints in
+// memory, which is mostly useful for tests and short-lived processes.
+func NewMemoryCheckpointStore() CheckpointStore {
+	return &memoryCheckpointStore{checkpoints: make(checkpoints)}
+}
+
+func (m *memoryCheckpointStore) Load(ctx context.Context, groupName, streamName string) (*Checkpoint, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.checkpoints.get(groupName, streamName), nil
+}
+
+func (m *memoryCheckpointStore) Save(ctx context.Context, groupName, streamName string, checkpoint Checkpoint) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.checkpoints.set(groupName, streamName, checkpoint)
+	return nil
+}
+
+type fileCheckpointStore struct {
+	path string
+	sync.Mutex
+}
+
+// NewFileCheckpointStore returns a CheckpointStore keeping checkpoints for all
+// streams in a single local JSON file. The file is created on first save, and
+// replaced atomically on every save.
+func NewFileCheckpointStore(path string) CheckpointStore {
+	return &fileCheckpointStore{path: path}
+}
+
+func (f *fileCheckpointStore) Load(ctx context.Context, groupName, streamName string) (*Checkpoint, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	all, err := f.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return all.get(groupName, streamName), nil
+}
+
+func (f *fileCheckpointStore) Save(ctx context.Context, groupName, streamName string, checkpoint Checkpoint) error {
+	f.Lock()
+	defer f.Unlock()
+
+	all, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	all.set(groupName, streamName, checkpoint)
+
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not encode checkpoints")
+	}
+
+	return errors.Wrap(writeFileAtomically(f.path, data), "could not write the checkpoints file")
+}
+
+func (f *fileCheckpointStore) read() (checkpoints, error) {
+	ret := make(checkpoints)
+
+	data, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return ret, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not read the checkpoints file")
+	}
+
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, errors.Wrap(err, "could not decode the checkpoints file")
+	}
+
+	return ret, nil
+}
+
+// writeFileAtomically replaces the file at path with one holding data, so that
+// the file is never left partially written.
+func writeFileAtomically(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "could not create a temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "could not write the temporary file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "could not write the temporary file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), path), "could not replace the file")
+}
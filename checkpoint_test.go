@@ -0,0 +1,137 @@
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type checkpointTestSuite struct {
+	suite.Suite
+
+	ctx                   context.Context
+	groupName, streamName string
+	sut                   Group
+}
+
+func (c *checkpointTestSuite) SetupTest() {
+	c.ctx = context.Background()
+	c.groupName = "groupName"
+	c.streamName = "streamName"
+
+	_, c.sut = newMemoryGroup(c.T(), c.groupName)
+
+	writer, err := c.sut.Create(c.ctx, c.streamName)
+	c.Require().NoError(err)
+
+	_, err = io.WriteString(writer, "a\nb\nc\n")
+	c.Require().NoError(err)
+	c.Require().NoError(writer.Close())
+}
+
+func (c *checkpointTestSuite) TestResumeMidPage() {
+	iterator := c.sut.Events(c.ctx, c.streamName, Snapshot())
+	c.Require().True(iterator.Next(c.ctx))
+	c.Equal("a\n", iterator.Event().Message)
+	c.Require().NoError(iterator.Close())
+
+	checkpoint := iterator.Checkpoint()
+	c.Equal("", checkpoint.Token)
+	c.Equal(1, checkpoint.Skip)
+	c.Equal(iterator.Event().Timestamp, checkpoint.Timestamp)
+
+	reader := c.sut.Open(c.ctx, c.streamName, Snapshot(), FromCheckpoint(checkpoint))
+	defer reader.Close()
+
+	rest, err := ioutil.ReadAll(reader)
+	c.NoError(err)
+	c.Equal("b\nc\n", string(rest))
+}
+
+func (c *checkpointTestSuite) TestResumeAtEndOfPage() {
+	reader := c.sut.Open(c.ctx, c.streamName, Snapshot())
+	all, err := ioutil.ReadAll(reader)
+	c.Require().NoError(err)
+	c.Equal("a\nb\nc\n", string(all))
+	c.Require().NoError(reader.Close())
+
+	checkpoint := reader.Checkpoint()
+	c.NotEmpty(checkpoint.Token)
+	c.Zero(checkpoint.Skip)
+
+	serialized, err := json.Marshal(checkpoint)
+	c.Require().NoError(err)
+
+	var restored Checkpoint
+	c.Require().NoError(json.Unmarshal(serialized, &restored))
+
+	reader = c.sut.Open(c.ctx, c.streamName, Snapshot(), FromCheckpoint(restored))
+	defer reader.Close()
+
+	rest, err := ioutil.ReadAll(reader)
+	c.NoError(err)
+	c.Empty(rest)
+	c.Equal(checkpoint.Token, reader.Checkpoint().Token)
+}
+
+func (c *checkpointTestSuite) TestStore() {
+	dir, err := ioutil.TempDir("", "checkpoints")
+	c.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "checkpoints.json")
+
+	for _, store := range []CheckpointStore{NewMemoryCheckpointStore(), NewFileCheckpointStore(path)} {
+		checkpoint, err := store.Load(c.ctx, c.groupName, c.streamName)
+		c.Require().NoError(err)
+		c.Require().Nil(checkpoint)
+
+		iterator := c.sut.Events(c.ctx, c.streamName, Snapshot(), WithCheckpointStore(store, time.Hour))
+		c.Require().True(iterator.Next(c.ctx))
+		c.Require().NoError(iterator.Close())
+
+		checkpoint, err = store.Load(c.ctx, c.groupName, c.streamName)
+		c.Require().NoError(err)
+		c.Require().NotNil(checkpoint)
+		c.Equal(1, checkpoint.Skip)
+
+		reader := c.sut.Open(c.ctx, c.streamName, Snapshot(), WithCheckpointStore(store, time.Hour))
+		rest, err := ioutil.ReadAll(reader)
+		c.NoError(err)
+		c.Equal("b\nc\n", string(rest))
+		c.Require().NoError(reader.Close())
+
+		checkpoint, err = store.Load(c.ctx, c.groupName, c.streamName)
+		c.Require().NoError(err)
+		c.Require().NotNil(checkpoint)
+		c.Equal(reader.Checkpoint().Token, checkpoint.Token)
+		c.True(reader.Checkpoint().Timestamp.Equal(checkpoint.Timestamp))
+	}
+}
+
+func (c *checkpointTestSuite) TestStoreLoadedInBackground() {
+	store := NewMemoryCheckpointStore()
+	checkpoint := Checkpoint{Skip: 2, Timestamp: time.Unix(1, 0)}
+	c.Require().NoError(store.Save(c.ctx, c.groupName, c.streamName, checkpoint))
+
+	reader := c.sut.Open(c.ctx, c.streamName, Snapshot(), WithCheckpointStore(store, time.Hour))
+	defer reader.Close()
+
+	// The checkpoint is loaded concurrently with this call.
+	reader.Checkpoint()
+
+	rest, err := ioutil.ReadAll(reader)
+	c.NoError(err)
+	c.Equal("c\n", string(rest))
+}
+
+func TestCheckpoint(t *testing.T) {
+	suite.Run(t, new(checkpointTestSuite))
+}
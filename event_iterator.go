@@ -0,0 +1,62 @@
+package cloudwatch
+
+import (
+	"context"
+	"io"
+)
+
+type eventIterator struct {
+	reader *readerImpl
+
+	current *Event
+	err     error
+}
+
+func (i *eventIterator) Next(ctx context.Context) bool {
+	for {
+		if i.reader.closed() {
+			i.err = io.ErrClosedPipe
+			return false
+		}
+
+		if i.current = i.reader.queue.pop(); i.current != nil {
+			return true
+		}
+
+		if err := i.reader.error(); err != nil {
+			// Reaching the end of the stream in snapshot mode is not an
+			// error.
+			if err != io.EOF {
+				i.err = err
+			}
+			return false
+		}
+
+		select {
+		case <-ctx.Done():
+			i.err = ctx.Err()
+			return false
+		case <-i.reader.ctx.Done():
+			i.err = i.reader.ctx.Err()
+			return false
+		case <-i.reader.done:
+		case <-i.reader.notify:
+		}
+	}
+}
+
+func (i *eventIterator) Event() *Event {
+	return i.current
+}
+
+func (i *eventIterator) Err() error {
+	return i.err
+}
+
+func (i *eventIterator) Checkpoint() Checkpoint {
+	return i.reader.Checkpoint()
+}
+
+func (i *eventIterator) Close() error {
+	return i.reader.Close()
+}
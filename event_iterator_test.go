@@ -0,0 +1,84 @@
+package cloudwatch
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/suite"
+)
+
+type eventIteratorTestSuite struct {
+	suite.Suite
+
+	api                   *MemoryAPI
+	ctx                   context.Context
+	groupName, streamName string
+	sut                   Group
+}
+
+func (e *eventIteratorTestSuite) SetupTest() {
+	e.ctx = context.Background()
+	e.groupName = "groupName"
+	e.streamName = "streamName"
+
+	e.api, e.sut = newMemoryGroup(e.T(), e.groupName)
+}
+
+func (e *eventIteratorTestSuite) TestSnapshot() {
+	timestamp := time.Now().Truncate(time.Millisecond)
+
+	writer, err := e.sut.Create(e.ctx, e.streamName, freezeTime(timestamp))
+	e.Require().NoError(err)
+
+	_, err = io.WriteString(writer, "Hello\nWorld")
+	e.Require().NoError(err)
+	e.Require().NoError(writer.Close())
+
+	iterator := e.sut.Events(e.ctx, e.streamName, Snapshot())
+	defer iterator.Close()
+
+	var messages []string
+	for iterator.Next(e.ctx) {
+		event := iterator.Event()
+		e.Equal(e.streamName, event.StreamName)
+		e.True(timestamp.Equal(event.Timestamp))
+		e.False(event.IngestionTime.IsZero())
+		messages = append(messages, event.Message)
+	}
+
+	e.NoError(iterator.Err())
+	e.Equal([]string{"Hello\n", "World"}, messages)
+}
+
+func (e *eventIteratorTestSuite) TestNextContextCancelled() {
+	_, err := e.api.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(e.groupName),
+		LogStreamName: aws.String(e.streamName),
+	})
+	e.Require().NoError(err)
+
+	iterator := e.sut.Events(e.ctx, e.streamName)
+	defer iterator.Close()
+
+	ctx, cancel := context.WithTimeout(e.ctx, 10*time.Millisecond)
+	defer cancel()
+
+	e.False(iterator.Next(ctx))
+	e.Equal(context.DeadlineExceeded, iterator.Err())
+}
+
+func (e *eventIteratorTestSuite) TestClose() {
+	iterator := e.sut.Events(e.ctx, e.streamName)
+
+	e.NoError(iterator.Close())
+	e.False(iterator.Next(e.ctx))
+	e.Equal(io.ErrClosedPipe, iterator.Err())
+}
+
+func TestEventIterator(t *testing.T) {
+	suite.Run(t, new(eventIteratorTestSuite))
+}
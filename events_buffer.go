@@ -2,6 +2,7 @@ package cloudwatch
 
 import (
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 )
@@ -11,6 +12,10 @@ import (
 type eventsBuffer struct {
 	sync.RWMutex
 	head, tail *logBatch
+
+	// The number of events in all batches, and their size including the
+	// per-event overhead.
+	count, size int
 }
 
 func newEventsBuffer() *eventsBuffer {
@@ -19,16 +24,28 @@ func newEventsBuffer() *eventsBuffer {
 }
 
 func (b *eventsBuffer) add(event *cloudwatchlogs.InputLogEvent) {
+	if event.Message == nil {
+		return
+	}
+
 	b.Lock()
 	defer b.Unlock()
 	b.tail = b.tail.add(event)
+	if b.tail.since.IsZero() {
+		b.tail.since = time.Now()
+	}
+	b.count++
+	b.size += len(*event.Message) + paddingSize
 }
 
 func (b *eventsBuffer) drain() []*cloudwatchlogs.InputLogEvent {
 	b.Lock()
 	defer b.Unlock()
 
-	ret := b.head.events
+	ret := b.head.sorted()
+	b.count -= b.head.count
+	b.size -= b.head.size
+
 	if b.head == b.tail {
 		b.head = new(logBatch)
 		b.tail = b.head
@@ -38,6 +55,69 @@ func (b *eventsBuffer) drain() []*cloudwatchlogs.InputLogEvent {
 	return ret
 }
 
+// discard drops all buffered events, and returns how many there were.
+func (b *eventsBuffer) discard() int {
+	b.Lock()
+	defer b.Unlock()
+
+	var ret int
+	for batch := b.head; batch != nil; batch = batch.next {
+		ret += len(batch.events)
+	}
+
+	b.head = new(logBatch)
+	b.tail = b.head
+	b.count, b.size = 0, 0
+	return ret
+}
+
+// dropOldest drops the earliest buffered event and returns it, or nil if the
+// buffer is empty.
+func (b *eventsBuffer) dropOldest() *cloudwatchlogs.InputLogEvent {
+	b.Lock()
+	defer b.Unlock()
+
+	if len(b.head.events) == 0 {
+		return nil
+	}
+
+	event := b.head.events[0]
+	size := len(*event.Message) + paddingSize
+	b.head.events[0] = nil
+	b.head.events = b.head.events[1:]
+	b.head.count--
+	b.head.size -= size
+	b.count--
+	b.size -= size
+
+	if len(b.head.events) == 0 && b.head != b.tail {
+		b.head = b.head.next
+	}
+	return event
+}
+
+// ready checks whether a full batch is buffered.
+func (b *eventsBuffer) ready() bool {
+	b.RLock()
+	defer b.RUnlock()
+	return b.head != b.tail || b.head.count >= maxBatchSizeEvents
+}
+
+// oldest returns when the earliest batch started being buffered, or the zero
+// time if the buffer is empty.
+func (b *eventsBuffer) oldest() time.Time {
+	b.RLock()
+	defer b.RUnlock()
+	return b.head.since
+}
+
+// len returns the number of buffered events and their size.
+func (b *eventsBuffer) len() (count, size int) {
+	b.RLock()
+	defer b.RUnlock()
+	return b.count, b.size
+}
+
 func (b *eventsBuffer) hasMore() bool {
 	b.RLock()
 	defer b.RUnlock()
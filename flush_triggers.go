@@ -0,0 +1,94 @@
+package cloudwatch
+
+import (
+	"time"
+)
+
+// WithMaxLatency makes the writer send buffered events as soon as a full batch
+// is ready, or once the earliest of them was buffered maxLatency ago, rather
+// than on every throttling interval. Either way, the writer doesn't send more
+// often than CloudWatch Logs allows for a single stream.
+func WithMaxLatency(maxLatency time.Duration) CreateOption {
+	return func(w *writerImpl) {
+		w.maxLatency = maxLatency
+	}
+}
+
+// WithIdleTimeout makes the writer send buffered events as soon as a full
+// batch is ready, or once nothing was written for idleTimeout, rather than on
+// every throttling interval. As long as data keeps being written, events are
+// only sent in full batches unless WithMaxLatency is used as well.
+func WithIdleTimeout(idleTimeout time.Duration) CreateOption {
+	return func(w *writerImpl) {
+		w.idleTimeout = idleTimeout
+	}
+}
+
+// flushDue checks whether the background goroutine should send buffered
+// events. Without triggers, it sends them on every throttling interval.
+func (w *writerImpl) flushDue() bool {
+	if w.maxLatency <= 0 && w.idleTimeout <= 0 {
+		return true
+	}
+
+	if !w.events.hasMore() {
+		return false
+	} else if w.events.ready() {
+		return true
+	}
+
+	if w.maxLatency > 0 && time.Since(w.events.oldest()) >= w.maxLatency {
+		return true
+	}
+	return w.idleTimeout > 0 && time.Since(w.lastWritten()) >= w.idleTimeout
+}
+
+// waitForData blocks until data is written, or until the next trigger may be
+// due. It never blocks for longer than the throttling interval, so that the
+// timeouts of pending lines are still checked.
+func (w *writerImpl) waitForData() {
+	wait := writeThrottle
+
+	if w.events.hasMore() {
+		if w.maxLatency > 0 {
+			wait = minDuration(wait, w.maxLatency-time.Since(w.events.oldest()))
+		}
+		if w.idleTimeout > 0 {
+			wait = minDuration(wait, w.idleTimeout-time.Since(w.lastWritten()))
+		}
+	}
+
+	if wait <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-w.written:
+	case <-timer.C:
+	}
+}
+
+// signalWritten wakes up the background goroutine waiting for data, if any.
+func (w *writerImpl) signalWritten() {
+	select {
+	case w.written <- struct{}{}:
+	default:
+	}
+}
+
+func (w *writerImpl) lastWritten() time.Time {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+
+	return w.writtenAt
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
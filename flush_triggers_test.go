@@ -0,0 +1,87 @@
+package cloudwatch
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type flushTriggersTestSuite struct {
+	suite.Suite
+
+	ctx                   context.Context
+	groupName, streamName string
+	api                   *MemoryAPI
+	sut                   Group
+}
+
+func (f *flushTriggersTestSuite) SetupTest() {
+	f.ctx = context.Background()
+	f.groupName = "groupName"
+	f.streamName = "streamName"
+
+	f.api, f.sut = newMemoryGroup(f.T(), f.groupName)
+}
+
+func (f *flushTriggersTestSuite) TestFlushesFullBatches() {
+	writer, err := f.sut.Create(f.ctx, f.streamName, WithMaxLatency(time.Hour))
+	f.Require().NoError(err)
+
+	_, err = io.WriteString(writer, strings.Repeat("Hello\n", maxBatchSizeEvents+1))
+	f.Require().NoError(err)
+
+	f.Eventually(func() bool { return f.count() == maxBatchSizeEvents }, time.Second, 10*time.Millisecond)
+
+	// The remaining event waits for the latency limit.
+	time.Sleep(2 * writeThrottle)
+	f.Equal(maxBatchSizeEvents, f.count())
+
+	f.Require().NoError(writer.Close())
+	f.Equal(maxBatchSizeEvents+1, f.count())
+}
+
+func (f *flushTriggersTestSuite) TestFlushesAfterMaxLatency() {
+	writer, err := f.sut.Create(f.ctx, f.streamName, WithMaxLatency(3*writeThrottle))
+	f.Require().NoError(err)
+	defer writer.Close()
+
+	start := time.Now()
+	_, err = io.WriteString(writer, "Hello\n")
+	f.Require().NoError(err)
+
+	f.Eventually(func() bool { return f.count() == 1 }, time.Second, 10*time.Millisecond)
+	f.True(time.Since(start) >= 3*writeThrottle)
+}
+
+func (f *flushTriggersTestSuite) TestFlushesWhenIdle() {
+	writer, err := f.sut.Create(f.ctx, f.streamName, WithMaxLatency(time.Hour), WithIdleTimeout(3*writeThrottle))
+	f.Require().NoError(err)
+	defer writer.Close()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err = io.WriteString(writer, "Hello\n")
+		f.Require().NoError(err)
+		time.Sleep(writeThrottle)
+	}
+
+	f.Zero(f.count())
+	f.Eventually(func() bool { return f.count() == 3 }, time.Second, 10*time.Millisecond)
+	f.True(time.Since(start) >= 5*writeThrottle)
+}
+
+func (f *flushTriggersTestSuite) TestFlushesOnEveryIntervalByDefault() {
+	f.True(new(writerImpl).flushDue())
+}
+
+func (f *flushTriggersTestSuite) count() int {
+	return len(storedMessages(f.T(), f.api, f.groupName, f.streamName))
+}
+
+func TestFlushTriggers(t *testing.T) {
+	suite.Run(t, new(flushTriggersTestSuite))
+}
@@ -0,0 +1,131 @@
+package cloudwatch
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Framer splits data written to a stream into event messages.
+type Framer interface {
+	// Frame is called with data which hasn't been framed yet, and returns
+	// the number of bytes making up the first frame along with the message
+	// to send for it. It returns 0 if data doesn't hold a complete frame. If
+	// atEnd is set, no more data is expected before the data is sent, so
+	// delimiter-based framers should return the remaining data as a frame.
+	// Returning an empty message skips the frame.
+	Frame(data []byte, atEnd bool) (advance int, message []byte)
+}
+
+// FramerFunc is an adapter allowing the use of ordinary functions as Framers.
+type FramerFunc func(data []byte, atEnd bool) (advance int, message []byte)
+
+// Frame calls f(data, atEnd).
+func (f FramerFunc) Frame(data []byte, atEnd bool) (int, []byte) {
+	return f(data, atEnd)
+}
+
+// WithFramer sets the Framer used to split written data into events. The
+// default is Newlines.
+func WithFramer(framer Framer) CreateOption {
+	return func(w *writerImpl) {
+		w.framer = framer
+	}
+}
+
+// Newlines returns a Framer which splits data into lines terminated by "\n",
+// keeping the terminator as part of the message.
+func Newlines() Framer {
+	return FramerFunc(func(data []byte, atEnd bool) (int, []byte) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			return i + 1, data[:i+1]
+		}
+		return remainder(data, atEnd)
+	})
+}
+
+// CRLF returns a Framer which splits data into lines terminated by "\r\n" or
+// "\n", normalizing the terminator of each message to "\n".
+func CRLF() Framer {
+	return FramerFunc(func(data []byte, atEnd bool) (int, []byte) {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return remainder(data, atEnd)
+		}
+
+		if i > 0 && data[i-1] == '\r' {
+			return i + 1, append(data[:i-1:i-1], '\n')
+		}
+		return i + 1, data[:i+1]
+	})
+}
+
+// Delimiter returns a Framer which splits data into frames separated by
+// delimiter, which is not part of the message.
+func Delimiter(delimiter byte) Framer {
+	return FramerFunc(func(data []byte, atEnd bool) (int, []byte) {
+		if i := bytes.IndexByte(data, delimiter); i >= 0 {
+			return i + 1, data[:i]
+		}
+		return remainder(data, atEnd)
+	})
+}
+
+// NULSeparated returns a Framer which splits data into frames separated by
+// NUL bytes, as produced by e.g. `find -print0`.
+func NULSeparated() Framer {
+	return Delimiter(0)
+}
+
+// DefaultMaxFrameSize is the largest message accepted by LengthPrefixed.
+const DefaultMaxFrameSize = maxBatchSizeBytes
+
+// LengthPrefixed returns a Framer for binary-safe producers, where each frame
+// is a message preceded by its length as a 32-bit big-endian unsigned integer.
+// Incomplete frames are never sent. Frames larger than DefaultMaxFrameSize are
+// skipped without being held in memory.
+func LengthPrefixed() Framer {
+	return LengthPrefixedLimit(DefaultMaxFrameSize)
+}
+
+// LengthPrefixedLimit is like LengthPrefixed, but skips frames larger than
+// maxSize bytes. As the returned Framer keeps track of the frame being
+// skipped, it must not be shared between writers.
+func LengthPrefixedLimit(maxSize int) Framer {
+	const prefixSize = 4
+
+	var skipping int // Bytes of an oversized frame left to skip.
+
+	return FramerFunc(func(data []byte, atEnd bool) (int, []byte) {
+		if skipping > 0 {
+			advance := skipping
+			if advance > len(data) {
+				advance = len(data)
+			}
+			skipping -= advance
+			return advance, nil
+		}
+
+		if len(data) < prefixSize {
+			return 0, nil
+		}
+
+		size := int64(binary.BigEndian.Uint32(data))
+		if size > int64(maxSize) {
+			skipping = int(size)
+			return prefixSize, nil
+		}
+
+		if int64(len(data)-prefixSize) < size {
+			return 0, nil
+		}
+
+		return prefixSize + int(size), data[prefixSize : prefixSize+int(size)]
+	})
+}
+
+func remainder(data []byte, atEnd bool) (int, []byte) {
+	if atEnd {
+		return len(data), data
+	}
+	return 0, nil
+}
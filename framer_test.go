@@ -0,0 +1,111 @@
+package cloudwatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type framerTestSuite struct {
+	suite.Suite
+}
+
+func (f *framerTestSuite) TestNewlines() {
+	f.Equal([]string{"a\n", "b\n", "c"}, f.frame(Newlines(), "a\nb\nc", true))
+	f.Equal([]string{"a\n", "b\n"}, f.frame(Newlines(), "a\nb\nc", false))
+}
+
+func (f *framerTestSuite) TestCRLF() {
+	f.Equal([]string{"a\n", "b\n", "c"}, f.frame(CRLF(), "a\r\nb\nc", true))
+}
+
+func (f *framerTestSuite) TestNULSeparated() {
+	f.Equal([]string{"a", "b\nc"}, f.frame(NULSeparated(), "a\x00\x00b\nc\x00", true))
+}
+
+func (f *framerTestSuite) TestDelimiter() {
+	f.Equal([]string{"a", "b"}, f.frame(Delimiter('|'), "a|b", true))
+	f.Equal([]string{"a"}, f.frame(Delimiter('|'), "a|b", false))
+}
+
+func (f *framerTestSuite) TestLengthPrefixed() {
+	f.Equal([]string{"a\nb", "cd"}, f.frame(LengthPrefixed(), "\x00\x00\x00\x03a\nb\x00\x00\x00\x02cd\x00\x00\x00\x05e", true))
+}
+
+func (f *framerTestSuite) TestWriterHoldsIncompleteFrames() {
+	writer := &writerImpl{events: newEventsBuffer()}
+	WithFramer(LengthPrefixed())(writer)
+
+	for _, chunk := range []string{"\x00\x00", "\x00\x05Hel", "lo\x00\x00\x00\x01"} {
+		n, err := writer.Write([]byte(chunk))
+		f.Require().NoError(err)
+		f.Equal(len(chunk), n)
+	}
+
+	events := writer.events.drain()
+	f.Require().Len(events, 1)
+	f.Equal("Hello", *events[0].Message)
+
+	writer.flushPending(true)
+	f.Equal("\x00\x00\x00\x01", string(writer.partial))
+	f.False(writer.events.hasMore())
+
+	writer.dropPartial()
+	f.Empty(writer.partial)
+	f.EqualValues(1, writer.Stats().Unframed)
+}
+
+func (f *framerTestSuite) TestWriterKeepsIncompleteFramesOnFlush() {
+	writer := &writerImpl{events: newEventsBuffer()}
+	WithFramer(LengthPrefixed())(writer)
+
+	_, err := writer.Write([]byte("\x00\x00\x00\x05he"))
+	f.Require().NoError(err)
+
+	writer.flushPending(true)
+	f.False(writer.events.hasMore())
+
+	_, err = writer.Write([]byte("llo\x00\x00\x00\x05world"))
+	f.Require().NoError(err)
+
+	var messages []string
+	for _, event := range writer.events.drain() {
+		messages = append(messages, *event.Message)
+	}
+	f.Equal([]string{"hello", "world"}, messages)
+	f.Zero(writer.Stats().Unframed)
+}
+
+func (f *framerTestSuite) TestLengthPrefixedSkipsOversizedFrames() {
+	framer := LengthPrefixedLimit(3)
+
+	f.Empty(f.frame(framer, "\x00\x00\x00\x05abc", false))
+	f.Equal([]string{"cd"}, f.frame(framer, "de\x00\x00\x00\x02cd", false))
+
+	// The prefix of a corrupt frame doesn't make the framer wait for it.
+	advance, message := LengthPrefixed().Frame([]byte("\xff\xff\xff\xffab"), false)
+	f.Equal(4, advance)
+	f.Nil(message)
+}
+
+func (f *framerTestSuite) frame(framer Framer, input string, atEnd bool) []string {
+	var ret []string
+
+	data := []byte(input)
+	for len(data) > 0 {
+		advance, message := framer.Frame(data, atEnd)
+		if advance == 0 {
+			break
+		}
+		if len(message) > 0 {
+			ret = append(ret, string(message))
+		}
+		data = data[advance:]
+	}
+
+	return ret
+}
+
+func TestFramer(t *testing.T) {
+	suite.Run(t, new(framerTestSuite))
+}
@@ -2,7 +2,6 @@ package cloudwatch
 
 import (
 	"context"
-	"io"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -41,14 +40,14 @@ func NewGroup(client iface.CloudWatchLogsAPI, groupName string) Group {
 	}
 }
 
-func (g *groupImpl) Create(ctx context.Context, streamName string, opts ...CreateOption) (io.WriteCloser, error) {
-	ret, err := g.create(ctx, streamName)
+func (g *groupImpl) Create(ctx context.Context, streamName string, opts ...CreateOption) (Writer, error) {
+	ret, err := g.create(ctx, streamName, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, opt := range opts {
-		opt(ret)
+	if err := ret.openSpool(); err != nil {
+		return nil, err
 	}
 
 	go ret.start()
@@ -59,29 +58,60 @@ func (g *groupImpl) Name() string {
 	return g.groupName
 }
 
-func (g *groupImpl) Open(ctx context.Context, streamName string) io.Reader {
+func (g *groupImpl) Open(ctx context.Context, streamName string, opts ...OpenOption) Reader {
+	ret := g.open(ctx, streamName, opts...)
+	go ret.start()
+	return ret
+}
+
+func (g *groupImpl) Events(ctx context.Context, streamName string, opts ...OpenOption) EventIterator {
+	ret := &eventIterator{reader: g.open(ctx, streamName, opts...)}
+	go ret.reader.start()
+	return ret
+}
+
+func (g *groupImpl) open(ctx context.Context, streamName string, opts ...OpenOption) *readerImpl {
+	ticker := time.NewTicker(readThrottle)
+
 	ret := &readerImpl{
 		client:     g,
 		ctx:        ctx,
 		groupName:  aws.String(g.groupName),
 		streamName: aws.String(streamName),
-		throttle:   time.Tick(readThrottle),
+		retries:    DefaultRetryPolicy,
+		throttle:   ticker.C,
+		ticker:     ticker,
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
 	}
 
-	go ret.start()
+	for _, opt := range opts {
+		opt(ret)
+	}
+
+	ret.running.Add(1)
 	return ret
 }
 
-func (g *groupImpl) create(ctx context.Context, streamName string) (*writerImpl, error) {
+func (g *groupImpl) create(ctx context.Context, streamName string, opts ...CreateOption) (*writerImpl, error) {
 	ret := &writerImpl{
 		client:     g,
 		ctx:        ctx,
 		events:     newEventsBuffer(),
 		groupName:  aws.String(g.groupName),
 		streamName: aws.String(streamName),
+		retries:    DefaultRetryPolicy,
 		throttle:   time.Tick(writeThrottle),
+		space:      make(chan struct{}, 1),
+		written:    make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(ret)
 	}
 
+	ret.inFlight = make(chan struct{}, ret.maxConcurrency())
+
 	unlock := g.locker.Lock(streamName)
 	defer unlock()
 
@@ -96,6 +126,12 @@ func (g *groupImpl) create(ctx context.Context, streamName string) (*writerImpl,
 		return nil, errors.Wrap(err, "could not create the log stream")
 	}
 
+	// There's no need to look up the token if it's not used, or if the caller
+	// provided one.
+	if ret.noSequenceTokens || ret.sequenceToken != nil {
+		return ret, nil
+	}
+
 	description, err := g.DescribeLogStreamsWithContext(ctx, &cloudwatchlogs.DescribeLogStreamsInput{
 		LogGroupName:        aws.String(g.groupName),
 		LogStreamNamePrefix: aws.String(streamName),
@@ -55,6 +55,46 @@ func (gs *groupTestSuite) TestCreateWithExistingStream_OK() {
 	gs.Equal(sequenceToken, *writer.(*writerImpl).sequenceToken)
 }
 
+func (gs *groupTestSuite) TestCreateWithExistingStream_WithoutSequenceTokens() {
+	gs.creatingLogStreamReturns(new(cloudwatchlogs.ResourceAlreadyExistsException))
+
+	writer, err := gs.sut.Create(gs.ctx, gs.streamName, WithoutSequenceTokens())
+
+	gs.Require().NotNil(writer)
+	gs.NoError(err)
+
+	gs.Nil(writer.(*writerImpl).sequenceToken)
+	gs.api.AssertNotCalled(gs.T(), "DescribeLogStreamsWithContext")
+}
+
+func (gs *groupTestSuite) TestCreateWithExistingStream_FromToken() {
+	gs.creatingLogStreamReturns(new(cloudwatchlogs.ResourceAlreadyExistsException))
+
+	writer, err := gs.sut.Create(gs.ctx, gs.streamName, FromToken("sequenceToken"))
+
+	gs.Require().NotNil(writer)
+	gs.NoError(err)
+
+	gs.Equal("sequenceToken", *writer.(*writerImpl).sequenceToken)
+	gs.api.AssertNotCalled(gs.T(), "DescribeLogStreamsWithContext")
+}
+
+func (gs *groupTestSuite) TestRetriesByDefault() {
+	gs.creatingLogStreamReturns(nil)
+
+	writer, err := gs.sut.Create(gs.ctx, gs.streamName)
+	gs.Require().NoError(err)
+	gs.Equal(DefaultRetryPolicy, writer.(*writerImpl).retries)
+
+	writer, err = gs.sut.Create(gs.ctx, gs.streamName, WithWriteRetries(RetryPolicy{}))
+	gs.Require().NoError(err)
+	gs.Equal(RetryPolicy{}, writer.(*writerImpl).retries)
+
+	reader := gs.sut.Open(gs.ctx, gs.streamName, Snapshot())
+	defer reader.Close()
+	gs.Equal(DefaultRetryPolicy, reader.(*readerImpl).retries)
+}
+
 func (gs *groupTestSuite) TestCreateWithExistingStream_UnexpectedFailure() {
 	const sequenceToken = "sequenceToken"
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
 
@@ -11,30 +12,185 @@ import (
 )
 
 // RejectedLogEventsInfoError wraps `cloudwatchlogs.RejectedLogEventsInfo` from
-// the AWS SDK, and makes it an implementation of Go's error interface.
+// the AWS SDK, and makes it an implementation of Go's error interface. It also
+// carries the rejected events, resolved from the indices in Info.
 type RejectedLogEventsInfoError struct {
 	Info *cloudwatchlogs.RejectedLogEventsInfo
+
+	// TooOld, TooNew and Expired hold the events rejected for being too old,
+	// too far in the future and older than the retention period, respectively.
+	// An event can be both too old and expired.
+	TooOld, TooNew, Expired []*cloudwatchlogs.InputLogEvent
+}
+
+func newRejectedLogEventsInfoError(info *cloudwatchlogs.RejectedLogEventsInfo, events []*cloudwatchlogs.InputLogEvent) *RejectedLogEventsInfoError {
+	// Events up to (but excluding) an end index are rejected, and so are
+	// events starting from a start index.
+	index := func(value *int64, fallback int) int {
+		if value == nil || *value < 0 {
+			return fallback
+		} else if int(*value) > len(events) {
+			return len(events)
+		}
+		return int(*value)
+	}
+
+	return &RejectedLogEventsInfoError{
+		Info:    info,
+		TooOld:  events[:index(info.TooOldLogEventEndIndex, 0)],
+		TooNew:  events[index(info.TooNewLogEventStartIndex, len(events)):],
+		Expired: events[:index(info.ExpiredLogEventEndIndex, 0)],
+	}
+}
+
+// Count returns the number of distinct rejected events.
+func (e *RejectedLogEventsInfoError) Count() int {
+	prefix := len(e.TooOld)
+	if len(e.Expired) > prefix {
+		prefix = len(e.Expired)
+	}
+	return prefix + len(e.TooNew)
 }
 
 func (e *RejectedLogEventsInfoError) Error() string {
-	return fmt.Sprintf("log messages were rejected")
+	return fmt.Sprintf(
+		"%d log messages were rejected (%d too old, %d too new, %d expired)",
+		e.Count(), len(e.TooOld), len(e.TooNew), len(e.Expired),
+	)
+}
+
+func isRejection(err error) bool {
+	_, ok := err.(*RejectedLogEventsInfoError)
+	return ok
+}
+
+// SequenceConflictError is returned when sending a batch keeps failing with
+// InvalidSequenceTokenException, most likely because other writers keep
+// writing to the same stream.
+type SequenceConflictError struct {
+	// Conflicts is the number of InvalidSequenceTokenExceptions seen.
+	Conflicts int
+
+	// Err is the last InvalidSequenceTokenException.
+	Err error
+}
+
+func (e *SequenceConflictError) Error() string {
+	return fmt.Sprintf("gave up after %d sequence token conflicts: %v", e.Conflicts, e.Err)
+}
+
+// ShutdownError is returned by Writer.Shutdown when some of the buffered events
+// could not be sent to AWS CloudWatch Logs.
+type ShutdownError struct {
+	// Dropped is the number of events which were not sent.
+	Dropped int
+
+	// Err is the reason why they were not sent.
+	Err error
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("%d log events were dropped: %v", e.Dropped, e.Err)
+}
+
+// WriterStats holds counters describing the events handled by a Writer.
+type WriterStats struct {
+	// TooOld and TooNew count events with timestamps which CloudWatch Logs
+	// would reject, and which were handled according to the writer's policy.
+	TooOld, TooNew int64
+
+	// Rejected counts events which were rejected by CloudWatch Logs.
+	Rejected int64
+
+	// Dropped counts events which were dropped because the writer's buffer
+	// was full.
+	Dropped int64
+
+	// Unframed counts incomplete frames which were dropped when the writer
+	// was closed, because the framer refused to frame them.
+	Unframed int64
 }
 
 // CreateOption allows setting various options on the resulting writer.
 type CreateOption func(*writerImpl)
 
+// OpenOption allows setting various options on the resulting reader.
+type OpenOption func(*readerImpl)
+
 // Group is an abstraction over AWS CloudWatch Logs Group, allowing one to treat
 // it like a remote io.ReadWriter.
 type Group interface {
 	cloudwatchlogsiface.CloudWatchLogsAPI
 
-	// Create creates a log stream in the managed group and returns an
-	// implementation of io.Writer to write to it.
-	Create(ctx context.Context, streamName string, opts ...CreateOption) (io.WriteCloser, error)
+	// Create creates a log stream in the managed group and returns a Writer
+	// to write to it.
+	Create(ctx context.Context, streamName string, opts ...CreateOption) (Writer, error)
+
+	// Events returns an EventIterator to read individual events from the log
+	// stream, preserving their timestamps and boundaries.
+	Events(ctx context.Context, streamName string, opts ...OpenOption) EventIterator
 
 	// Name of the CloudWatch Logs group owned by this proxy.
 	Name() string
 
-	// Open returns an io.Reader to read from the log stream.
-	Open(ctx context.Context, streamName string) io.Reader
+	// Open returns a Reader to read from the log stream. Closing it stops
+	// polling the stream.
+	Open(ctx context.Context, streamName string, opts ...OpenOption) Reader
+}
+
+// Writer is an io.WriteCloser writing to a CloudWatch Logs stream.
+type Writer interface {
+	io.WriteCloser
+
+	// Flush synchronously sends all buffered events to CloudWatch Logs and
+	// reports the result, without closing the writer.
+	Flush() error
+
+	// Sync is an alias for Flush.
+	Sync() error
+
+	// Shutdown closes the writer, sending as many buffered events as possible
+	// before ctx is done, and reports how many were dropped.
+	Shutdown(ctx context.Context) error
+
+	// Stats returns counters describing the events handled by the writer.
+	Stats() WriterStats
+}
+
+// Reader is an io.ReadCloser reading from a CloudWatch Logs stream.
+type Reader interface {
+	io.ReadCloser
+
+	// Checkpoint returns the position just after the last event fully read,
+	// which can be passed to FromCheckpoint to resume reading from there.
+	Checkpoint() Checkpoint
+}
+
+// Event is a single log event read from a CloudWatch Logs stream.
+type Event struct {
+	Message       string
+	Timestamp     time.Time
+	IngestionTime time.Time
+	StreamName    string
+}
+
+// EventIterator allows reading individual events from a log stream. Closing
+// it stops polling the stream.
+type EventIterator interface {
+	io.Closer
+
+	// Next blocks until the next event is available, and returns false if
+	// there will be no more events, either because the end of the stream was
+	// reached in snapshot mode, ctx was cancelled or reading failed.
+	Next(ctx context.Context) bool
+
+	// Event returns the event most recently made available by Next.
+	Event() *Event
+
+	// Err returns the error which caused Next to return false, if any.
+	Err() error
+
+	// Checkpoint returns the position just after the event most recently
+	// made available by Next.
+	Checkpoint() Checkpoint
 }
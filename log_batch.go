@@ -1,6 +1,11 @@
 package cloudwatch
 
 import (
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
 )
 
@@ -9,27 +14,128 @@ import (
 const (
 	maxBatchSizeBytes  = 1048576
 	maxBatchSizeEvents = 10000
+	maxEventSizeBytes  = 262144
 	paddingSize        = 26
+
+	maxMessageSizeBytes = maxEventSizeBytes - paddingSize
+
+	maxBatchTimeSpan  = 24 * time.Hour
+	maxEventAge       = 14 * 24 * time.Hour
+	maxEventFutureAge = 2 * time.Hour
+)
+
+// CloudWatch Logs timestamps are in milliseconds since the Unix epoch.
+func millisFromTime(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func timeFromMillis(millis int64) time.Time {
+	return time.Unix(0, millis*int64(time.Millisecond))
+}
+
+const (
+	// ContinuationMarker ends every part of a message too large for a single
+	// event but the last one, so that readers can reassemble the message.
+	ContinuationMarker = "[continued]"
+
+	// TruncationMarker ends messages truncated to fit in a single event.
+	TruncationMarker = "[truncated]"
 )
 
 type logBatch struct {
 	count, size int
 	events      []*cloudwatchlogs.InputLogEvent
 	next        *logBatch
+
+	// The range of event timestamps in the batch, in milliseconds.
+	minTimestamp, maxTimestamp int64
+
+	// When the first event was added to the batch.
+	since time.Time
 }
 
 func (l *logBatch) add(event *cloudwatchlogs.InputLogEvent) *logBatch {
 	if event.Message == nil {
 		return l
 	}
-	l.count++
 	nextSize := l.size + len(*event.Message) + paddingSize
-	if nextSize > maxBatchSizeBytes || l.count > maxBatchSizeEvents {
+	if nextSize > maxBatchSizeBytes || l.count >= maxBatchSizeEvents || !l.spans(event) {
 		l.next = new(logBatch)
 		return l.next.add(event)
 
 	}
+
+	timestamp := aws.Int64Value(event.Timestamp)
+	if l.count == 0 || timestamp < l.minTimestamp {
+		l.minTimestamp = timestamp
+	}
+	if l.count == 0 || timestamp > l.maxTimestamp {
+		l.maxTimestamp = timestamp
+	}
+
+	l.count++
 	l.events = append(l.events, event)
 	l.size = nextSize
 	return l
 }
+
+// spans checks whether adding the event would keep the batch within the
+// maximum time span allowed for a single PutLogEvents call.
+func (l *logBatch) spans(event *cloudwatchlogs.InputLogEvent) bool {
+	if l.count == 0 {
+		return true
+	}
+
+	timestamp := aws.Int64Value(event.Timestamp)
+	maxSpan := int64(maxBatchTimeSpan / time.Millisecond)
+
+	return timestamp-l.minTimestamp <= maxSpan && l.maxTimestamp-timestamp <= maxSpan
+}
+
+// sorted returns the events in the batch in chronological order, as required
+// by PutLogEvents. Events with equal timestamps keep their order.
+func (l *logBatch) sorted() []*cloudwatchlogs.InputLogEvent {
+	sort.SliceStable(l.events, func(i, j int) bool {
+		return aws.Int64Value(l.events[i].Timestamp) < aws.Int64Value(l.events[j].Timestamp)
+	})
+	return l.events
+}
+
+// splitMessage splits a message into parts which fit in a single event each,
+// without breaking UTF-8 sequences. All parts but the last one end with
+// ContinuationMarker.
+func splitMessage(message string) []string {
+	var ret []string
+
+	for len(message) > maxMessageSizeBytes {
+		cut := utf8Cut(message, maxMessageSizeBytes-len(ContinuationMarker))
+		ret = append(ret, message[:cut]+ContinuationMarker)
+		message = message[cut:]
+	}
+
+	return append(ret, message)
+}
+
+// truncateMessage truncates a message to fit in a single event, without
+// breaking UTF-8 sequences, and marks it with TruncationMarker.
+func truncateMessage(message string) string {
+	if len(message) <= maxMessageSizeBytes {
+		return message
+	}
+	return message[:utf8Cut(message, maxMessageSizeBytes-len(TruncationMarker))] + TruncationMarker
+}
+
+// utf8Cut returns the largest index not greater than max at which s can be
+// cut without breaking a UTF-8 sequence.
+func utf8Cut(s string, max int) int {
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	// Not UTF-8 after all, so there is nothing to preserve.
+	if cut == 0 {
+		return max
+	}
+	return cut
+}
@@ -0,0 +1,97 @@
+package cloudwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/suite"
+)
+
+type logBatchTestSuite struct {
+	suite.Suite
+
+	sut *eventsBuffer
+}
+
+func (l *logBatchTestSuite) SetupTest() {
+	l.sut = newEventsBuffer()
+}
+
+func (l *logBatchTestSuite) TestSortsEvents() {
+	l.add("b", 2*time.Second)
+	l.add("a", time.Second)
+	l.add("c", 2*time.Second)
+
+	l.Equal([]string{"a", "b", "c"}, l.drain())
+	l.False(l.sut.hasMore())
+}
+
+func (l *logBatchTestSuite) TestSplitsBatchesExceedingTimeSpan() {
+	l.add("a", 0)
+	l.add("b", maxBatchTimeSpan)
+	l.add("c", maxBatchTimeSpan+time.Millisecond)
+	l.add("d", time.Hour)
+
+	l.Equal([]string{"a", "b"}, l.drain())
+	l.Equal([]string{"d", "c"}, l.drain())
+	l.False(l.sut.hasMore())
+}
+
+func (l *logBatchTestSuite) TestSplitsBatchesExceedingEventCount() {
+	for i := 0; i <= maxBatchSizeEvents; i++ {
+		l.add("a", 0)
+	}
+
+	l.Len(l.drain(), maxBatchSizeEvents)
+	l.Len(l.drain(), 1)
+}
+
+func (l *logBatchTestSuite) TestTracksBufferedEvents() {
+	l.add("a", 0)
+	l.add("b", maxBatchTimeSpan+time.Millisecond)
+
+	count, size := l.sut.len()
+	l.Equal(2, count)
+	l.Equal(2+2*paddingSize, size)
+
+	l.Equal([]string{"a"}, l.drain())
+	count, size = l.sut.len()
+	l.Equal(1, count)
+	l.Equal(1+paddingSize, size)
+}
+
+func (l *logBatchTestSuite) TestDropsOldestEvent() {
+	l.add("a", 0)
+	l.add("b", maxBatchTimeSpan+time.Millisecond)
+	l.add("c", maxBatchTimeSpan+time.Millisecond)
+
+	l.Equal("a", *l.sut.dropOldest().Message)
+	l.Equal("b", *l.sut.dropOldest().Message)
+
+	count, _ := l.sut.len()
+	l.Equal(1, count)
+	l.Equal([]string{"c"}, l.drain())
+
+	l.Nil(l.sut.dropOldest())
+}
+
+func (l *logBatchTestSuite) add(message string, offset time.Duration) {
+	l.sut.add(&cloudwatchlogs.InputLogEvent{
+		Message:   aws.String(message),
+		Timestamp: aws.Int64(millisFromTime(time.Unix(0, 0).Add(offset))),
+	})
+}
+
+func (l *logBatchTestSuite) drain() []string {
+	var ret []string
+	for _, event := range l.sut.drain() {
+		ret = append(ret, *event.Message)
+	}
+	return ret
+}
+
+func TestLogBatch(t *testing.T) {
+	suite.Run(t, new(logBatchTestSuite))
+}
@@ -0,0 +1,476 @@
+package cloudwatch
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	iface "github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+)
+
+// Limits enforced by the service on GetLogEvents, see
+// https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_GetLogEvents.html
+const (
+	maxGetLogEventsCount = 10000
+	maxGetLogEventsBytes = 1048576
+)
+
+// MemoryAPI is a stateful, in-memory implementation of the subset of
+// cloudwatchlogsiface.CloudWatchLogsAPI used by this package. It keeps track
+// of log groups, streams, their events and upload sequence tokens, and mimics
+// the errors returned by the real service, so that it can be passed to
+// NewGroup in unit tests which should not talk to AWS.
+//
+// Calling methods which are not implemented will panic.
+type MemoryAPI struct {
+	iface.CloudWatchLogsAPI
+
+	// IgnoreSequenceTokens makes PutLogEvents accept any sequence token,
+	// including none, like the service does nowadays. By default tokens are
+	// validated like older endpoints used to.
+	IgnoreSequenceTokens bool
+
+	groups    map[string]*memoryGroup
+	lastToken int64
+	nowFunc   func() time.Time
+
+	sync.Mutex
+}
+
+type memoryGroup struct {
+	createdAt time.Time
+	streams   map[string]*memoryStream
+}
+
+type memoryStream struct {
+	createdAt     time.Time
+	events        []*cloudwatchlogs.OutputLogEvent
+	sequenceToken *string
+	storedBytes   int64
+}
+
+// NewMemoryAPI returns an empty MemoryAPI.
+func NewMemoryAPI() *MemoryAPI {
+	return &MemoryAPI{groups: make(map[string]*memoryGroup)}
+}
+
+// CreateLogGroup creates a log group.
+func (m *MemoryAPI) CreateLogGroup(input *cloudwatchlogs.CreateLogGroupInput) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	return m.CreateLogGroupWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateLogGroupWithContext creates a log group, failing with
+// ResourceAlreadyExistsException if it already exists.
+func (m *MemoryAPI) CreateLogGroupWithContext(ctx aws.Context, input *cloudwatchlogs.CreateLogGroupInput, opts ...request.Option) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	name := aws.StringValue(input.LogGroupName)
+	if _, exists := m.groups[name]; exists {
+		return nil, &cloudwatchlogs.ResourceAlreadyExistsException{
+			Message_: aws.String("The specified log group already exists"),
+		}
+	}
+
+	m.groups[name] = &memoryGroup{createdAt: m.now(), streams: make(map[string]*memoryStream)}
+	return &cloudwatchlogs.CreateLogGroupOutput{}, nil
+}
+
+// DeleteLogGroup deletes a log group along with all its streams.
+func (m *MemoryAPI) DeleteLogGroup(input *cloudwatchlogs.DeleteLogGroupInput) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	return m.DeleteLogGroupWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteLogGroupWithContext deletes a log group along with all its streams.
+func (m *MemoryAPI) DeleteLogGroupWithContext(ctx aws.Context, input *cloudwatchlogs.DeleteLogGroupInput, opts ...request.Option) (*cloudwatchlogs.DeleteLogGroupOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if _, err := m.group(input.LogGroupName); err != nil {
+		return nil, err
+	}
+
+	delete(m.groups, aws.StringValue(input.LogGroupName))
+	return &cloudwatchlogs.DeleteLogGroupOutput{}, nil
+}
+
+// CreateLogStream creates a log stream in an existing log group.
+func (m *MemoryAPI) CreateLogStream(input *cloudwatchlogs.CreateLogStreamInput) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	return m.CreateLogStreamWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateLogStreamWithContext creates a log stream in an existing log group,
+// failing with ResourceAlreadyExistsException if it already exists.
+func (m *MemoryAPI) CreateLogStreamWithContext(ctx aws.Context, input *cloudwatchlogs.CreateLogStreamInput, opts ...request.Option) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	group, err := m.group(input.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	name := aws.StringValue(input.LogStreamName)
+	if _, exists := group.streams[name]; exists {
+		return nil, &cloudwatchlogs.ResourceAlreadyExistsException{
+			Message_: aws.String("The specified log stream already exists"),
+		}
+	}
+
+	group.streams[name] = &memoryStream{createdAt: m.now()}
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nil
+}
+
+// DeleteLogStream deletes a log stream along with all its events.
+func (m *MemoryAPI) DeleteLogStream(input *cloudwatchlogs.DeleteLogStreamInput) (*cloudwatchlogs.DeleteLogStreamOutput, error) {
+	return m.DeleteLogStreamWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteLogStreamWithContext deletes a log stream along with all its events.
+func (m *MemoryAPI) DeleteLogStreamWithContext(ctx aws.Context, input *cloudwatchlogs.DeleteLogStreamInput, opts ...request.Option) (*cloudwatchlogs.DeleteLogStreamOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	group, err := m.group(input.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.stream(input.LogGroupName, input.LogStreamName); err != nil {
+		return nil, err
+	}
+
+	delete(group.streams, aws.StringValue(input.LogStreamName))
+	return &cloudwatchlogs.DeleteLogStreamOutput{}, nil
+}
+
+// DescribeLogStreams lists log streams in a log group.
+func (m *MemoryAPI) DescribeLogStreams(input *cloudwatchlogs.DescribeLogStreamsInput) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	return m.DescribeLogStreamsWithContext(aws.BackgroundContext(), input)
+}
+
+// DescribeLogStreamsWithContext lists log streams in a log group, ordered by
+// name and optionally filtered by name prefix. Pagination is not supported.
+func (m *MemoryAPI) DescribeLogStreamsWithContext(ctx aws.Context, input *cloudwatchlogs.DescribeLogStreamsInput, opts ...request.Option) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	group, err := m.group(input.LogGroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := aws.StringValue(input.LogStreamNamePrefix)
+
+	var names []string
+	for name := range group.streams {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if aws.BoolValue(input.Descending) {
+		sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	}
+
+	if limit := int(aws.Int64Value(input.Limit)); limit > 0 && limit < len(names) {
+		names = names[:limit]
+	}
+
+	ret := &cloudwatchlogs.DescribeLogStreamsOutput{}
+	for _, name := range names {
+		ret.LogStreams = append(ret.LogStreams, group.streams[name].describe(name))
+	}
+
+	return ret, nil
+}
+
+// PutLogEvents uploads a batch of log events to a log stream.
+func (m *MemoryAPI) PutLogEvents(input *cloudwatchlogs.PutLogEventsInput) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	return m.PutLogEventsWithContext(aws.BackgroundContext(), input)
+}
+
+// PutLogEventsWithContext uploads a batch of log events to a log stream. It
+// validates the sequence token and the batch constraints the same way the
+// service does, and reports events which are too old or too far in the future
+// using RejectedLogEventsInfo.
+func (m *MemoryAPI) PutLogEventsWithContext(ctx aws.Context, input *cloudwatchlogs.PutLogEventsInput, opts ...request.Option) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	stream, err := m.stream(input.LogGroupName, input.LogStreamName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateBatch(input.LogEvents); err != nil {
+		return nil, err
+	}
+
+	if !m.IgnoreSequenceTokens && aws.StringValue(input.SequenceToken) != aws.StringValue(stream.sequenceToken) {
+		return nil, &cloudwatchlogs.InvalidSequenceTokenException{
+			ExpectedSequenceToken: stream.sequenceToken,
+			Message_:              aws.String("The given sequenceToken is invalid"),
+		}
+	}
+
+	now := m.now()
+	tooOldEnd, tooNewStart := 0, len(input.LogEvents)
+
+	for i, event := range input.LogEvents {
+		timestamp := timeFromMillis(aws.Int64Value(event.Timestamp))
+		if timestamp.Before(now.Add(-maxEventAge)) {
+			tooOldEnd = i + 1
+		} else if timestamp.After(now.Add(maxEventFutureAge)) && i < tooNewStart {
+			tooNewStart = i
+		}
+	}
+
+	ingestionTime := aws.Int64(millisFromTime(now))
+	for _, event := range input.LogEvents[tooOldEnd:tooNewStart] {
+		stream.events = append(stream.events, &cloudwatchlogs.OutputLogEvent{
+			IngestionTime: ingestionTime,
+			Message:       aws.String(aws.StringValue(event.Message)),
+			Timestamp:     aws.Int64(aws.Int64Value(event.Timestamp)),
+		})
+		stream.storedBytes += int64(len(aws.StringValue(event.Message)))
+	}
+
+	m.lastToken++
+	stream.sequenceToken = aws.String(fmt.Sprintf("%056d", m.lastToken))
+
+	ret := &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: stream.sequenceToken}
+
+	if tooOldEnd > 0 || tooNewStart < len(input.LogEvents) {
+		ret.RejectedLogEventsInfo = &cloudwatchlogs.RejectedLogEventsInfo{}
+		if tooOldEnd > 0 {
+			ret.RejectedLogEventsInfo.TooOldLogEventEndIndex = aws.Int64(int64(tooOldEnd))
+		}
+		if tooNewStart < len(input.LogEvents) {
+			ret.RejectedLogEventsInfo.TooNewLogEventStartIndex = aws.Int64(int64(tooNewStart))
+		}
+	}
+
+	return ret, nil
+}
+
+// GetLogEvents reads log events from a log stream.
+func (m *MemoryAPI) GetLogEvents(input *cloudwatchlogs.GetLogEventsInput) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	return m.GetLogEventsWithContext(aws.BackgroundContext(), input)
+}
+
+// GetLogEventsWithContext reads log events from a log stream. Forward ("f/")
+// and backward ("b/") tokens work like the ones issued by the service: once
+// the end of the stream is reached, the returned NextForwardToken is the same
+// as the one passed in.
+func (m *MemoryAPI) GetLogEventsWithContext(ctx aws.Context, input *cloudwatchlogs.GetLogEventsInput, opts ...request.Option) (*cloudwatchlogs.GetLogEventsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	stream, err := m.stream(input.LogGroupName, input.LogStreamName)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := int(aws.Int64Value(input.Limit))
+	if limit <= 0 || limit > maxGetLogEventsCount {
+		limit = maxGetLogEventsCount
+	}
+
+	var matching []int
+	for i, event := range stream.events {
+		timestamp := aws.Int64Value(event.Timestamp)
+		if input.StartTime != nil && timestamp < *input.StartTime {
+			continue
+		}
+		if input.EndTime != nil && timestamp >= *input.EndTime {
+			continue
+		}
+		matching = append(matching, i)
+	}
+
+	forward := aws.BoolValue(input.StartFromHead)
+	position := len(stream.events)
+	if forward {
+		position = 0
+	}
+
+	if input.NextToken != nil {
+		if position, forward, err = parseMemoryToken(*input.NextToken); err != nil {
+			return nil, err
+		}
+	}
+
+	// Find the window of matching events, in terms of indices into matching.
+	start := sort.SearchInts(matching, position)
+	end := start
+	size := 0
+
+	if forward {
+		for end < len(matching) && end-start < limit {
+			size += len(aws.StringValue(stream.events[matching[end]].Message)) + paddingSize
+			if size > maxGetLogEventsBytes {
+				break
+			}
+			end++
+		}
+	} else {
+		for start > 0 && end-start < limit {
+			size += len(aws.StringValue(stream.events[matching[start-1]].Message)) + paddingSize
+			if size > maxGetLogEventsBytes {
+				break
+			}
+			start--
+		}
+	}
+
+	ret := &cloudwatchlogs.GetLogEventsOutput{
+		Events:            make([]*cloudwatchlogs.OutputLogEvent, 0, end-start),
+		NextBackwardToken: aws.String(fmt.Sprintf("b/%d", position)),
+		NextForwardToken:  aws.String(fmt.Sprintf("f/%d", position)),
+	}
+
+	for _, index := range matching[start:end] {
+		event := *stream.events[index]
+		ret.Events = append(ret.Events, &event)
+	}
+
+	if start < end {
+		ret.NextBackwardToken = aws.String(fmt.Sprintf("b/%d", matching[start]))
+		ret.NextForwardToken = aws.String(fmt.Sprintf("f/%d", matching[end-1]+1))
+	}
+
+	return ret, nil
+}
+
+func (m *MemoryAPI) group(name *string) (*memoryGroup, error) {
+	group, exists := m.groups[aws.StringValue(name)]
+	if !exists {
+		return nil, &cloudwatchlogs.ResourceNotFoundException{
+			Message_: aws.String("The specified log group does not exist."),
+		}
+	}
+	return group, nil
+}
+
+func (m *MemoryAPI) stream(groupName, streamName *string) (*memoryStream, error) {
+	group, err := m.group(groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, exists := group.streams[aws.StringValue(streamName)]
+	if !exists {
+		return nil, &cloudwatchlogs.ResourceNotFoundException{
+			Message_: aws.String("The specified log stream does not exist."),
+		}
+	}
+	return stream, nil
+}
+
+func (m *MemoryAPI) now() time.Time {
+	if m.nowFunc == nil {
+		return time.Now()
+	}
+	return m.nowFunc()
+}
+
+func (s *memoryStream) describe(name string) *cloudwatchlogs.LogStream {
+	ret := &cloudwatchlogs.LogStream{
+		CreationTime:        aws.Int64(millisFromTime(s.createdAt)),
+		LogStreamName:       aws.String(name),
+		StoredBytes:         aws.Int64(s.storedBytes),
+		UploadSequenceToken: s.sequenceToken,
+	}
+
+	if len(s.events) > 0 {
+		first, last := s.events[0], s.events[len(s.events)-1]
+		ret.FirstEventTimestamp = first.Timestamp
+		ret.LastEventTimestamp = last.Timestamp
+		ret.LastIngestionTime = last.IngestionTime
+	}
+
+	return ret
+}
+
+func validateBatch(events []*cloudwatchlogs.InputLogEvent) error {
+	if len(events) == 0 {
+		return invalidParameter("at least one log event is required")
+	}
+
+	if len(events) > maxBatchSizeEvents {
+		return invalidParameter("too many log events in the batch")
+	}
+
+	size := 0
+	for i, event := range events {
+		if event.Message == nil || event.Timestamp == nil {
+			return invalidParameter("log events require a message and a timestamp")
+		}
+
+		size += len(*event.Message) + paddingSize
+
+		if i > 0 && *event.Timestamp < *events[i-1].Timestamp {
+			return invalidParameter("log events in a single PutLogEvents request must be in chronological order")
+		}
+	}
+
+	if size > maxBatchSizeBytes {
+		return invalidParameter("the batch of log events is too large")
+	}
+
+	first, last := *events[0].Timestamp, *events[len(events)-1].Timestamp
+	if time.Duration(last-first)*time.Millisecond > maxBatchTimeSpan {
+		return invalidParameter("the batch of log events in a single PutLogEvents request cannot span more than 24 hours")
+	}
+
+	return nil
+}
+
+func invalidParameter(message string) error {
+	return &cloudwatchlogs.InvalidParameterException{Message_: aws.String(message)}
+}
+
+func parseMemoryToken(token string) (position int, forward bool, err error) {
+	if len(token) > 2 && (token[:2] == "f/" || token[:2] == "b/") {
+		if position, err = strconv.Atoi(token[2:]); err == nil && position >= 0 {
+			return position, token[0] == 'f', nil
+		}
+	}
+	return 0, false, invalidParameter(fmt.Sprintf("invalid next token: %q", token))
+}
@@ -0,0 +1,271 @@
+package cloudwatch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type memoryAPITestSuite struct {
+	suite.Suite
+
+	ctx                   context.Context
+	groupName, streamName string
+	now                   time.Time
+	sut                   *MemoryAPI
+}
+
+func (m *memoryAPITestSuite) SetupTest() {
+	m.ctx = context.Background()
+	m.groupName = "groupName"
+	m.streamName = "streamName"
+	m.now = time.Unix(1500000000, 0)
+
+	m.sut = NewMemoryAPI()
+	m.sut.nowFunc = func() time.Time { return m.now }
+
+	_, err := m.sut.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: aws.String(m.groupName),
+	})
+	m.Require().NoError(err)
+
+	_, err = m.sut.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(m.groupName),
+		LogStreamName: aws.String(m.streamName),
+	})
+	m.Require().NoError(err)
+}
+
+func (m *memoryAPITestSuite) TestCreateLogStream_AlreadyExists() {
+	_, err := m.sut.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(m.groupName),
+		LogStreamName: aws.String(m.streamName),
+	})
+
+	m.IsType(new(cloudwatchlogs.ResourceAlreadyExistsException), err)
+}
+
+func (m *memoryAPITestSuite) TestCreateLogStream_MissingGroup() {
+	_, err := m.sut.CreateLogStream(&cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String("bacon"),
+		LogStreamName: aws.String(m.streamName),
+	})
+
+	m.IsType(new(cloudwatchlogs.ResourceNotFoundException), err)
+}
+
+func (m *memoryAPITestSuite) TestPutLogEvents_SequenceTokens() {
+	out, err := m.put(nil, m.event("Hello", 0))
+	m.Require().NoError(err)
+	m.Require().NotNil(out.NextSequenceToken)
+
+	_, err = m.put(nil, m.event("World", 0))
+	m.Require().IsType(new(cloudwatchlogs.InvalidSequenceTokenException), err)
+	m.Equal(out.NextSequenceToken, err.(*cloudwatchlogs.InvalidSequenceTokenException).ExpectedSequenceToken)
+
+	_, err = m.put(out.NextSequenceToken, m.event("World", 0))
+	m.NoError(err)
+
+	streams, err := m.sut.DescribeLogStreams(&cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName:        aws.String(m.groupName),
+		LogStreamNamePrefix: aws.String(m.streamName),
+	})
+	m.Require().NoError(err)
+	m.Require().Len(streams.LogStreams, 1)
+	m.NotEqual(out.NextSequenceToken, streams.LogStreams[0].UploadSequenceToken)
+}
+
+func (m *memoryAPITestSuite) TestPutLogEvents_IgnoreSequenceTokens() {
+	m.sut.IgnoreSequenceTokens = true
+
+	_, err := m.put(nil, m.event("Hello", 0))
+	m.Require().NoError(err)
+
+	_, err = m.put(nil, m.event("World", 0))
+	m.Require().NoError(err)
+
+	_, err = m.put(aws.String("bacon"), m.event("!", 0))
+	m.NoError(err)
+}
+
+func (m *memoryAPITestSuite) TestPutLogEvents_TooOld() {
+	out, err := m.put(nil, m.event("old", -maxEventAge-time.Hour), m.event("ok", -maxEventAge+time.Hour))
+	m.Require().NoError(err)
+	m.Require().NotNil(out.RejectedLogEventsInfo)
+	m.EqualValues(1, *out.RejectedLogEventsInfo.TooOldLogEventEndIndex)
+	m.Nil(out.RejectedLogEventsInfo.TooNewLogEventStartIndex)
+
+	m.Equal([]string{"ok"}, m.messages(m.get(&cloudwatchlogs.GetLogEventsInput{StartFromHead: aws.Bool(true)}).Events))
+}
+
+func (m *memoryAPITestSuite) TestPutLogEvents_TooNew() {
+	out, err := m.put(nil, m.event("ok", time.Hour), m.event("new", 3*time.Hour))
+	m.Require().NoError(err)
+	m.Require().NotNil(out.RejectedLogEventsInfo)
+	m.Nil(out.RejectedLogEventsInfo.TooOldLogEventEndIndex)
+	m.EqualValues(1, *out.RejectedLogEventsInfo.TooNewLogEventStartIndex)
+
+	m.Equal([]string{"ok"}, m.messages(m.get(&cloudwatchlogs.GetLogEventsInput{StartFromHead: aws.Bool(true)}).Events))
+}
+
+func (m *memoryAPITestSuite) TestPutLogEvents_TimeSpan() {
+	_, err := m.put(nil, m.event("Hello", -25*time.Hour), m.event("World", 0))
+
+	m.IsType(new(cloudwatchlogs.InvalidParameterException), err)
+}
+
+func (m *memoryAPITestSuite) TestPutLogEvents_OutOfOrder() {
+	_, err := m.put(nil, m.event("Hello", time.Second), m.event("World", 0))
+
+	m.IsType(new(cloudwatchlogs.InvalidParameterException), err)
+}
+
+func (m *memoryAPITestSuite) TestGetLogEvents_Tokens() {
+	_, err := m.put(nil, m.event("a", 0), m.event("b", 0), m.event("c", 0))
+	m.Require().NoError(err)
+
+	first := m.get(&cloudwatchlogs.GetLogEventsInput{StartFromHead: aws.Bool(true), Limit: aws.Int64(2)})
+	m.Equal([]string{"a", "b"}, m.messages(first.Events))
+
+	second := m.get(&cloudwatchlogs.GetLogEventsInput{NextToken: first.NextForwardToken})
+	m.Equal([]string{"c"}, m.messages(second.Events))
+
+	third := m.get(&cloudwatchlogs.GetLogEventsInput{NextToken: second.NextForwardToken})
+	m.Empty(third.Events)
+	m.Equal(*second.NextForwardToken, *third.NextForwardToken)
+
+	tail := m.get(&cloudwatchlogs.GetLogEventsInput{Limit: aws.Int64(1)})
+	m.Equal([]string{"c"}, m.messages(tail.Events))
+
+	previous := m.get(&cloudwatchlogs.GetLogEventsInput{NextToken: tail.NextBackwardToken})
+	m.Equal([]string{"a", "b"}, m.messages(previous.Events))
+}
+
+func (m *memoryAPITestSuite) TestGroupRoundTrip() {
+	m.sut.nowFunc = nil
+	group := NewGroup(m.sut, m.groupName)
+
+	writer, err := group.Create(m.ctx, m.streamName)
+	m.Require().NoError(err)
+
+	_, err = io.WriteString(writer, "Hello\nWorld\n")
+	m.Require().NoError(err)
+	m.Require().NoError(writer.Close())
+
+	reader := group.Open(m.ctx, m.streamName, Snapshot())
+	defer reader.Close()
+
+	buffer := new(bytes.Buffer)
+	_, err = io.Copy(buffer, reader)
+	m.NoError(err)
+	m.Equal("Hello\nWorld\n", buffer.String())
+}
+
+func (m *memoryAPITestSuite) TestGroupRoundTrip_WithoutSequenceTokens() {
+	m.sut.nowFunc = nil
+	m.sut.IgnoreSequenceTokens = true
+	group := NewGroup(m.sut, m.groupName)
+
+	first, err := group.Create(m.ctx, m.streamName, WithoutSequenceTokens())
+	m.Require().NoError(err)
+	second, err := group.Create(m.ctx, m.streamName, WithoutSequenceTokens())
+	m.Require().NoError(err)
+
+	_, err = io.WriteString(first, "Hello\n")
+	m.Require().NoError(err)
+	m.Require().NoError(first.Close())
+
+	_, err = io.WriteString(second, "World\n")
+	m.Require().NoError(err)
+	m.Require().NoError(second.Close())
+
+	reader := group.Open(m.ctx, m.streamName, Snapshot())
+	defer reader.Close()
+
+	buffer := new(bytes.Buffer)
+	_, err = io.Copy(buffer, reader)
+	m.NoError(err)
+	m.Equal("Hello\nWorld\n", buffer.String())
+}
+
+func (m *memoryAPITestSuite) put(token *string, events ...*cloudwatchlogs.InputLogEvent) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	return m.sut.PutLogEventsWithContext(m.ctx, &cloudwatchlogs.PutLogEventsInput{
+		LogEvents:     events,
+		LogGroupName:  aws.String(m.groupName),
+		LogStreamName: aws.String(m.streamName),
+		SequenceToken: token,
+	})
+}
+
+func (m *memoryAPITestSuite) get(input *cloudwatchlogs.GetLogEventsInput) *cloudwatchlogs.GetLogEventsOutput {
+	input.LogGroupName = aws.String(m.groupName)
+	input.LogStreamName = aws.String(m.streamName)
+
+	out, err := m.sut.GetLogEventsWithContext(m.ctx, input)
+	m.Require().NoError(err)
+	return out
+}
+
+func (m *memoryAPITestSuite) event(message string, offset time.Duration) *cloudwatchlogs.InputLogEvent {
+	return &cloudwatchlogs.InputLogEvent{
+		Message:   aws.String(message),
+		Timestamp: aws.Int64(millisFromTime(m.now.Add(offset))),
+	}
+}
+
+func (m *memoryAPITestSuite) messages(events []*cloudwatchlogs.OutputLogEvent) []string {
+	ret := make([]string, 0, len(events))
+	for _, event := range events {
+		ret = append(ret, *event.Message)
+	}
+	return ret
+}
+
+// newMemoryGroup returns a MemoryAPI holding an empty log group, and a Group
+// for writing to and reading from it.
+func newMemoryGroup(t *testing.T, groupName string) (*MemoryAPI, Group) {
+	api := NewMemoryAPI()
+
+	_, err := api.CreateLogGroup(&cloudwatchlogs.CreateLogGroupInput{
+		LogGroupName: aws.String(groupName),
+	})
+	require.NoError(t, err)
+
+	return api, NewGroup(api, groupName)
+}
+
+// storedMessages returns the messages of all events stored in the stream, in
+// order, paging through them as GetLogEvents returns at most 10000 at a time.
+func storedMessages(t *testing.T, api *MemoryAPI, groupName, streamName string) []string {
+	input := &cloudwatchlogs.GetLogEventsInput{
+		LogGroupName:  aws.String(groupName),
+		LogStreamName: aws.String(streamName),
+		StartFromHead: aws.Bool(true),
+	}
+
+	var ret []string
+	for {
+		out, err := api.GetLogEvents(input)
+		require.NoError(t, err)
+
+		if len(out.Events) == 0 {
+			return ret
+		}
+
+		for _, event := range out.Events {
+			ret = append(ret, *event.Message)
+		}
+		input.NextToken = out.NextForwardToken
+	}
+}
+
+func TestMemoryAPI(t *testing.T) {
+	suite.Run(t, new(memoryAPITestSuite))
+}
@@ -0,0 +1,63 @@
+package cloudwatch
+
+import (
+	"regexp"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithEventStartPattern groups consecutive lines into a single event, starting
+// a new event with each line matching pattern. This is useful for logs where
+// each entry starts with a timestamp or a log level, and may be followed by a
+// stack trace. The last group is sent once no line arrives for timeout, or
+// when the writer is flushed or closed. A zero timeout means no limit.
+func WithEventStartPattern(pattern *regexp.Regexp, timeout time.Duration) CreateOption {
+	return WithContinuationLines(func(line []byte) bool {
+		return !pattern.Match(line)
+	}, timeout)
+}
+
+// WithContinuationLines groups consecutive lines into a single event,
+// appending each line for which isContinuation returns true to the previous
+// event. The last group is sent once no line arrives for timeout, or when the
+// writer is flushed or closed. A zero timeout means no limit.
+func WithContinuationLines(isContinuation func(line []byte) bool, timeout time.Duration) CreateOption {
+	return func(w *writerImpl) {
+		w.continues = isContinuation
+		w.groupTimeout = timeout
+	}
+}
+
+// IsIndented reports whether line starts with whitespace, which is how Java
+// exceptions and Python tracebacks mark continuation lines. It's meant to be
+// used with WithContinuationLines.
+func IsIndented(line []byte) bool {
+	r, _ := utf8.DecodeRune(line)
+	return r != '\n' && r != '\r' && unicode.IsSpace(r)
+}
+
+// addLine turns a line into an event, or adds it to the current group of
+// lines if multi-line grouping is enabled.
+func (w *writerImpl) addLine(line []byte, timestamp time.Time) {
+	if w.continues == nil {
+		w.addEvent(line, timestamp)
+		return
+	}
+
+	if len(w.group) > 0 && !w.continues(line) {
+		w.flushGroup()
+	}
+
+	if len(w.group) == 0 {
+		w.groupSince = timestamp
+	}
+
+	w.group = append(w.group, line...)
+	w.groupUpdated = w.now()
+}
+
+func (w *writerImpl) flushGroup() {
+	w.addEvent(w.group, w.groupSince)
+	w.group = nil
+}
@@ -1,8 +1,8 @@
 package cloudwatch
 
 import (
-	"bytes"
 	"context"
+	"io"
 	"sync"
 	"time"
 
@@ -17,50 +17,254 @@ type readerImpl struct {
 	client iface.CloudWatchLogsAPI
 	ctx    context.Context
 
+	// In snapshot mode the reader stops at the current end of the stream
+	// rather than following it.
+	snapshot bool
+
+	// Optional bounds on the events to read, in milliseconds since the epoch,
+	// and the number of latest events to start from.
+	startTime, endTime *int64
+	tail               int64
+
+	// The number of events to skip on the first page when resuming from a
+	// checkpoint.
+	skip int
+
+	// If set, the checkpoint is loaded from the store when the reader starts,
+	// and saved to it every saveInterval and when the reader is closed.
+	store        CheckpointStore
+	saveInterval time.Duration
+	lastSaved    Checkpoint
+	lastSavedAt  time.Time
+
+	retries  RetryPolicy
 	throttle <-chan time.Time
-	buffer   lockingBuffer
+	ticker   *time.Ticker
+	queue    eventQueue
+
+	// done is closed when the reader is closed, and running tracks the
+	// background polling goroutine so that Close can wait for it to exit.
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	running   sync.WaitGroup
+
+	// notify is signalled whenever new data or an error becomes available, so
+	// that blocked calls to Read can wake up. It must have a capacity of 1.
+	notify chan struct{}
 
 	// If an error occurs when getting events from the stream, this will be
-	// populated and subsequent calls to Read will return the error.
-	err error
+	// populated and subsequent calls to Read will return the error once the
+	// buffered data has been consumed.
+	err   error
+	errMu sync.Mutex
 }
 
+// Read blocks until there is buffered data to return, the reader's context is
+// cancelled or getting events from the stream fails. In snapshot mode Read
+// returns io.EOF once the end of the stream is reached. Once the reader is
+// closed, Read returns io.ErrClosedPipe.
 func (r *readerImpl) Read(b []byte) (int, error) {
-	// Return the AWS error if there is one.
-	if r.err != nil {
-		return 0, r.err
-	}
-	// If there is not data right now, return. Reading from the buffer would
-	// result in io.EOF being returned, which is not what we want.
-	if r.buffer.Len() == 0 {
+	if len(b) == 0 {
 		return 0, nil
 	}
-	return r.buffer.Read(b)
+
+	for {
+		if r.closed() {
+			return 0, io.ErrClosedPipe
+		}
+
+		if n := r.queue.read(b); n > 0 {
+			return n, nil
+		}
+
+		if err := r.error(); err != nil {
+			return 0, err
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		case <-r.done:
+		case <-r.notify:
+		}
+	}
+}
+
+// Close stops polling the stream and waits for the background goroutine to
+// exit, then saves the checkpoint if a store is configured. Any subsequent
+// calls to Read will return io.ErrClosedPipe.
+func (r *readerImpl) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.done)
+		if r.ticker != nil {
+			r.ticker.Stop()
+		}
+		r.running.Wait()
+
+		// The reader's context may well be cancelled by now, but we still
+		// want to record how far we got.
+		r.closeErr = r.saveCheckpoint(context.Background())
+	})
+	return r.closeErr
+}
+
+// Checkpoint returns the position just after the last event fully consumed by
+// the caller.
+func (r *readerImpl) Checkpoint() Checkpoint {
+	return r.queue.position()
+}
+
+func (r *readerImpl) closed() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
 }
 
 func (r *readerImpl) start() {
+	defer r.running.Done()
+
+	if err := r.loadCheckpoint(); err != nil {
+		r.fail(err)
+		return
+	}
+
 	for {
-		<-r.throttle
-		if r.err = r.read(); r.err != nil {
+		select {
+		case <-r.ctx.Done():
+			r.fail(r.ctx.Err())
+			return
+		case <-r.done:
+			return
+		case <-r.throttle:
+		}
+
+		if err := r.read(); err != nil {
+			r.fail(err)
+			return
+		}
+
+		if time.Since(r.lastSavedAt) < r.saveInterval {
+			continue
+		}
+
+		if err := r.saveCheckpoint(r.ctx); err != nil {
+			r.fail(err)
 			return
 		}
 	}
 }
 
+func (r *readerImpl) error() error {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+
+	return r.err
+}
+
+func (r *readerImpl) fail(err error) {
+	r.errMu.Lock()
+	r.err = err
+	r.errMu.Unlock()
+
+	r.signal()
+}
+
+// signal wakes up a blocked Read, if any.
+func (r *readerImpl) signal() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Follow makes the reader wait for new events once it reaches the end of the
+// stream. This is the default.
+func Follow() OpenOption {
+	return func(r *readerImpl) {
+		r.snapshot = false
+	}
+}
+
+// Snapshot makes the reader return io.EOF once it reaches the current end of
+// the stream, which is useful for dumping a finished stream with io.Copy.
+func Snapshot() OpenOption {
+	return func(r *readerImpl) {
+		r.snapshot = true
+	}
+}
+
+// StartAt makes the reader skip events with a timestamp earlier than start.
+func StartAt(start time.Time) OpenOption {
+	return func(r *readerImpl) {
+		r.startTime = aws.Int64(millisFromTime(start))
+	}
+}
+
+// EndAt makes the reader skip events with a timestamp equal to or later than
+// end. Combine it with Snapshot to stop reading once end is reached.
+func EndAt(end time.Time) OpenOption {
+	return func(r *readerImpl) {
+		r.endTime = aws.Int64(millisFromTime(end))
+	}
+}
+
+// FromTail makes the reader start from the latest n events in the stream
+// rather than from its beginning. Until all of them are read, the reader's
+// checkpoint makes a reader opened with FromTail read the latest events again.
+func FromTail(n int) OpenOption {
+	return func(r *readerImpl) {
+		r.tail = int64(n)
+	}
+}
+
 func (r *readerImpl) read() error {
 	input := &cloudwatchlogs.GetLogEventsInput{
 		LogGroupName:  r.groupName,
 		LogStreamName: r.streamName,
 		StartFromHead: aws.Bool(true),
 		NextToken:     r.nextToken,
+		StartTime:     r.startTime,
+		EndTime:       r.endTime,
 	}
 
-	resp, err := r.client.GetLogEventsWithContext(r.ctx, input)
+	// Without a token, reading backwards from the end of the stream returns
+	// the latest events. Subsequent forward tokens require StartFromHead.
+	backwards := r.tail > 0 && r.nextToken == nil
+	if backwards {
+		input.StartFromHead = aws.Bool(false)
+		input.Limit = aws.Int64(r.tail)
+	}
+
+	var resp *cloudwatchlogs.GetLogEventsOutput
+	err := r.retries.do(r.ctx, func() (err error) {
+		resp, err = r.client.GetLogEventsWithContext(r.ctx, input)
+		return err
+	})
 
 	if err != nil {
 		return err
 	}
 
+	// The service returns the token we've passed in once we've reached the
+	// end of the stream.
+	if r.snapshot && r.nextToken != nil && aws.StringValue(resp.NextForwardToken) == *r.nextToken {
+		return io.EOF
+	}
+
+	// Skipping applies to the first page only, which is the one the
+	// checkpoint was taken on.
+	skip := r.skip
+	if skip > len(resp.Events) {
+		skip = len(resp.Events)
+	}
+	r.skip = 0
+
+	pageToken := aws.StringValue(r.nextToken)
+
 	// We want to re-use the existing token in the event that
 	// NextForwardToken is nil, which means there's no new messages to
 	// consume.
@@ -68,34 +272,122 @@ func (r *readerImpl) read() error {
 		r.nextToken = resp.NextForwardToken
 	}
 
+	events := resp.Events[skip:]
+
 	// If there are no messages, return so that the consumer can read again.
-	if len(resp.Events) == 0 {
+	if len(events) == 0 {
 		return nil
 	}
 
-	for _, event := range resp.Events {
-		r.buffer.WriteString(*event.Message)
+	queued := make([]*queuedEvent, 0, len(events))
+	for i, event := range events {
+		entry := &queuedEvent{
+			Event: &Event{
+				Message:       aws.StringValue(event.Message),
+				Timestamp:     timeFromMillis(aws.Int64Value(event.Timestamp)),
+				IngestionTime: timeFromMillis(aws.Int64Value(event.IngestionTime)),
+				StreamName:    aws.StringValue(r.streamName),
+			},
+		}
+
+		// Once the last event on the page is consumed, we can move on to
+		// the next page. Until then we need to remember how many events
+		// on the current one we've seen.
+		//
+		// A page read backwards has no token to resume from part-way, and
+		// skipping into the latest events would skip into a different page
+		// once the stream grows, so such a page is read again instead.
+		entry.checkpoint = Checkpoint{Token: pageToken, Skip: skip + i + 1, Timestamp: entry.Timestamp}
+		if backwards {
+			entry.checkpoint = Checkpoint{Timestamp: entry.Timestamp}
+		}
+		if i == len(events)-1 && resp.NextForwardToken != nil {
+			entry.checkpoint = Checkpoint{Token: *resp.NextForwardToken, Timestamp: entry.Timestamp}
+		}
+
+		queued = append(queued, entry)
 	}
 
+	r.queue.push(queued)
+	r.signal()
 	return nil
 }
 
-// lockingBuffer is a bytes.Buffer that locks Reads and Writes.
-type lockingBuffer struct {
+type queuedEvent struct {
+	*Event
+
+	// The checkpoint to record once this event is consumed.
+	checkpoint Checkpoint
+}
+
+// eventQueue holds events fetched from the stream until they're consumed,
+// keeping track of the checkpoint of the last consumed one.
+type eventQueue struct {
 	sync.Mutex
-	bytes.Buffer
+
+	events     []*queuedEvent
+	offset     int // Bytes of the first event's message read so far.
+	checkpoint Checkpoint
+}
+
+func (q *eventQueue) push(events []*queuedEvent) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.events = append(q.events, events...)
+}
+
+// pop returns the first event in the queue, or nil if the queue is empty.
+func (q *eventQueue) pop() *Event {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.events) == 0 {
+		return nil
+	}
+
+	head := q.events[0]
+	q.consume()
+	return head.Event
+}
+
+// read copies as many bytes of queued messages as fit in b.
+func (q *eventQueue) read(b []byte) (n int) {
+	q.Lock()
+	defer q.Unlock()
+
+	for n < len(b) && len(q.events) > 0 {
+		message := q.events[0].Message
+		copied := copy(b[n:], message[q.offset:])
+		n += copied
+		q.offset += copied
+
+		if q.offset == len(message) {
+			q.consume()
+		}
+	}
+
+	return n
+}
+
+func (q *eventQueue) consume() {
+	q.checkpoint = q.events[0].checkpoint
+	q.events[0] = nil
+	q.events = q.events[1:]
+	q.offset = 0
 }
 
-func (r *lockingBuffer) Read(b []byte) (int, error) {
-	r.Lock()
-	defer r.Unlock()
+func (q *eventQueue) position() Checkpoint {
+	q.Lock()
+	defer q.Unlock()
 
-	return r.Buffer.Read(b)
+	return q.checkpoint
 }
 
-func (r *lockingBuffer) Write(b []byte) (int, error) {
-	r.Lock()
-	defer r.Unlock()
+// setPosition sets the checkpoint to report until an event is consumed.
+func (q *eventQueue) setPosition(checkpoint Checkpoint) {
+	q.Lock()
+	defer q.Unlock()
 
-	return r.Buffer.Write(b)
+	q.checkpoint = checkpoint
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -18,13 +19,14 @@ type readerTestSuite struct {
 
 	api                   *mockAPI
 	ctx                   context.Context
+	cancel                context.CancelFunc
 	groupName, streamName string
-	sut                   io.Reader
+	sut                   Reader
 }
 
 func (r *readerTestSuite) SetupTest() {
 	r.api = new(mockAPI)
-	r.ctx = context.Background()
+	r.ctx, r.cancel = context.WithCancel(context.Background())
 	r.groupName = "groupName"
 	r.streamName = "streamName"
 
@@ -33,9 +35,15 @@ func (r *readerTestSuite) SetupTest() {
 		ctx:        r.ctx,
 		groupName:  aws.String(r.groupName),
 		streamName: aws.String(r.streamName),
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
 	}
 }
 
+func (r *readerTestSuite) TearDownTest() {
+	r.cancel()
+}
+
 func (r *readerTestSuite) TestSimpleRead() {
 	r.api.On(
 		"GetLogEventsWithContext",
@@ -153,11 +161,53 @@ func (r *readerTestSuite) TestEndOfFile() {
 	r.EqualValues("World", buffer[:n])
 
 	r.NoError(r.sut.(*readerImpl).read())
+	r.cancel()
 	n, err = r.sut.Read(buffer)
-	r.NoError(err)
+	r.Equal(context.Canceled, err)
 	r.Equal(0, n)
 }
 
+func (r *readerTestSuite) TestReadBlocksUntilData() {
+	r.api.On(
+		"GetLogEventsWithContext",
+		r.ctx,
+		&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String(r.groupName),
+			LogStreamName: aws.String(r.streamName),
+			StartFromHead: aws.Bool(true),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.GetLogEventsOutput{
+		Events: []*cloudwatchlogs.OutputLogEvent{
+			{Message: aws.String("Hello"), Timestamp: aws.Int64(1000)},
+		},
+	}, nil)
+
+	buffer := make([]byte, 5)
+	done := make(chan struct{})
+
+	var (
+		n   int
+		err error
+	)
+	go func() {
+		defer close(done)
+		n, err = r.sut.Read(buffer)
+	}()
+
+	select {
+	case <-done:
+		r.FailNow("Read should block until there's data")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	r.Require().NoError(r.sut.(*readerImpl).read())
+	<-done
+
+	r.NoError(err)
+	r.Equal("Hello", string(buffer[:n]))
+}
+
 func (r *readerTestSuite) TestReadError() {
 	r.sut = NewGroup(r.api, r.groupName).Open(r.ctx, r.streamName)
 
@@ -182,6 +232,145 @@ func (r *readerTestSuite) TestReadError() {
 	r.EqualError(err, errorMessage)
 }
 
+func (r *readerTestSuite) TestSnapshot() {
+	r.sut.(*readerImpl).snapshot = true
+
+	r.api.On(
+		"GetLogEventsWithContext",
+		r.ctx,
+		&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String(r.groupName),
+			LogStreamName: aws.String(r.streamName),
+			StartFromHead: aws.Bool(true),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.GetLogEventsOutput{
+		Events: []*cloudwatchlogs.OutputLogEvent{
+			{Message: aws.String("Hello"), Timestamp: aws.Int64(1000)},
+		},
+		NextForwardToken: aws.String("next"),
+	}, nil)
+
+	r.api.On(
+		"GetLogEventsWithContext",
+		r.ctx,
+		&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String(r.groupName),
+			LogStreamName: aws.String(r.streamName),
+			StartFromHead: aws.Bool(true),
+			NextToken:     aws.String("next"),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.GetLogEventsOutput{
+		Events:           []*cloudwatchlogs.OutputLogEvent{},
+		NextForwardToken: aws.String("next"),
+	}, nil)
+
+	r.NoError(r.sut.(*readerImpl).read())
+	r.Equal(io.EOF, r.sut.(*readerImpl).read())
+}
+
+func (r *readerTestSuite) TestFromTail() {
+	FromTail(10)(r.sut.(*readerImpl))
+
+	r.api.On(
+		"GetLogEventsWithContext",
+		r.ctx,
+		&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String(r.groupName),
+			LogStreamName: aws.String(r.streamName),
+			StartFromHead: aws.Bool(false),
+			Limit:         aws.Int64(10),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.GetLogEventsOutput{
+		NextForwardToken: aws.String("next"),
+	}, nil)
+
+	r.api.On(
+		"GetLogEventsWithContext",
+		r.ctx,
+		&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String(r.groupName),
+			LogStreamName: aws.String(r.streamName),
+			StartFromHead: aws.Bool(true),
+			NextToken:     aws.String("next"),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.GetLogEventsOutput{
+		NextForwardToken: aws.String("next"),
+	}, nil)
+
+	r.NoError(r.sut.(*readerImpl).read())
+	r.NoError(r.sut.(*readerImpl).read())
+	r.api.AssertExpectations(r.T())
+}
+
+func (r *readerTestSuite) TestFromTailCheckpoints() {
+	FromTail(2)(r.sut.(*readerImpl))
+
+	r.api.On(
+		"GetLogEventsWithContext",
+		r.ctx,
+		&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String(r.groupName),
+			LogStreamName: aws.String(r.streamName),
+			StartFromHead: aws.Bool(false),
+			Limit:         aws.Int64(2),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.GetLogEventsOutput{
+		Events: []*cloudwatchlogs.OutputLogEvent{
+			{Message: aws.String("a"), Timestamp: aws.Int64(1000)},
+			{Message: aws.String("b"), Timestamp: aws.Int64(2000)},
+		},
+		NextForwardToken: aws.String("next"),
+	}, nil)
+
+	r.Require().NoError(r.sut.(*readerImpl).read())
+
+	// The page has to be read again until it's fully consumed.
+	r.Require().NotNil(r.sut.(*readerImpl).queue.pop())
+	r.Equal(Checkpoint{Timestamp: time.Unix(1, 0)}, r.sut.Checkpoint())
+
+	r.Require().NotNil(r.sut.(*readerImpl).queue.pop())
+	r.Equal(Checkpoint{Token: "next", Timestamp: time.Unix(2, 0)}, r.sut.Checkpoint())
+}
+
+func (r *readerTestSuite) TestTimeBounds() {
+	StartAt(time.Unix(1, 0))(r.sut.(*readerImpl))
+	EndAt(time.Unix(2, 0))(r.sut.(*readerImpl))
+
+	r.api.On(
+		"GetLogEventsWithContext",
+		r.ctx,
+		&cloudwatchlogs.GetLogEventsInput{
+			LogGroupName:  aws.String(r.groupName),
+			LogStreamName: aws.String(r.streamName),
+			StartFromHead: aws.Bool(true),
+			StartTime:     aws.Int64(1000),
+			EndTime:       aws.Int64(2000),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.GetLogEventsOutput{}, nil)
+
+	r.NoError(r.sut.(*readerImpl).read())
+	r.api.AssertExpectations(r.T())
+}
+
+func (r *readerTestSuite) TestClose() {
+	r.sut = NewGroup(r.api, r.groupName).Open(r.ctx, r.streamName)
+
+	r.NoError(r.sut.Close())
+	r.NoError(r.sut.Close())
+
+	n, err := r.sut.Read(make([]byte, 5))
+	r.Equal(0, n)
+	r.Equal(io.ErrClosedPipe, err)
+
+	r.api.AssertNotCalled(r.T(), "GetLogEventsWithContext")
+}
+
 func TestReader(t *testing.T) {
 	suite.Run(t, new(readerTestSuite))
 }
@@ -0,0 +1,123 @@
+package cloudwatch
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+// RetryPolicy describes how failed requests to CloudWatch Logs are retried.
+// The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, doubled for each
+	// subsequent one up to MaxDelay.
+	BaseDelay, MaxDelay time.Duration
+
+	// Jitter is the fraction of each delay, between 0 and 1, which is
+	// randomized to avoid retrying in lockstep with other clients.
+	Jitter float64
+
+	// Retryable classifies errors as retryable. If nil, IsRetryable is used.
+	Retryable func(error) bool
+}
+
+// DefaultRetryPolicy retries throttling and transient errors up to 5 times,
+// waiting between 100ms and 5s with full jitter.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Jitter:      1,
+}
+
+// defaultSequenceConflicts is the default number of times sending a batch is
+// retried after InvalidSequenceTokenException, and sequenceConflictBackoff
+// governs the delays between those retries.
+const defaultSequenceConflicts = 5
+
+var sequenceConflictBackoff = RetryPolicy{
+	BaseDelay: 50 * time.Millisecond,
+	MaxDelay:  2 * time.Second,
+	Jitter:    1,
+}
+
+// WithWriteRetries sets the policy for retrying failed PutLogEvents requests.
+// The default is DefaultRetryPolicy, and the zero RetryPolicy disables retries.
+func WithWriteRetries(policy RetryPolicy) CreateOption {
+	return func(w *writerImpl) {
+		w.retries = policy
+	}
+}
+
+// WithReadRetries sets the policy for retrying failed GetLogEvents requests.
+// The default is DefaultRetryPolicy, and the zero RetryPolicy disables retries.
+func WithReadRetries(policy RetryPolicy) OpenOption {
+	return func(r *readerImpl) {
+		r.retries = policy
+	}
+}
+
+// IsRetryable reports whether err is a throttling or transient error, such as
+// ThrottlingException, ServiceUnavailableException or a network failure.
+func IsRetryable(err error) bool {
+	switch err := err.(type) {
+	case awserr.Error:
+		return err.Code() == cloudwatchlogs.ErrCodeServiceUnavailableException ||
+			request.IsErrorThrottle(err) ||
+			request.IsErrorRetryable(err)
+	case net.Error:
+		return request.IsErrorRetryable(err)
+	default:
+		return false
+	}
+}
+
+// do calls fn until it succeeds, fails with an error which is not retryable,
+// the maximum number of attempts is reached or ctx is done.
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(p.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns how long to wait before the given retry.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	ret := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay == 0 || ret < p.MaxDelay); i++ {
+		ret *= 2
+	}
+
+	if p.MaxDelay > 0 && ret > p.MaxDelay {
+		ret = p.MaxDelay
+	}
+
+	if jitter := int64(float64(ret) * p.Jitter); jitter > 0 {
+		ret -= time.Duration(rand.Int63n(jitter + 1))
+	}
+
+	return ret
+}
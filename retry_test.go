@@ -0,0 +1,105 @@
+package cloudwatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/suite"
+)
+
+type retryTestSuite struct {
+	suite.Suite
+
+	ctx context.Context
+	sut RetryPolicy
+}
+
+func (r *retryTestSuite) SetupTest() {
+	r.ctx = context.Background()
+	r.sut = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+}
+
+func (r *retryTestSuite) TestRetriesUntilSuccess() {
+	var attempts int
+
+	err := r.sut.do(r.ctx, func() error {
+		if attempts++; attempts < 3 {
+			return awserr.New("ThrottlingException", "slow down", nil)
+		}
+		return nil
+	})
+
+	r.NoError(err)
+	r.Equal(3, attempts)
+}
+
+func (r *retryTestSuite) TestGivesUpAfterMaxAttempts() {
+	var attempts int
+
+	err := r.sut.do(r.ctx, func() error {
+		attempts++
+		return awserr.New("ServiceUnavailableException", "try later", nil)
+	})
+
+	r.EqualError(err, "ServiceUnavailableException: try later")
+	r.Equal(3, attempts)
+}
+
+func (r *retryTestSuite) TestDoesNotRetryPermanentErrors() {
+	var attempts int
+
+	err := r.sut.do(r.ctx, func() error {
+		attempts++
+		return errors.New("bacon")
+	})
+
+	r.EqualError(err, "bacon")
+	r.Equal(1, attempts)
+}
+
+func (r *retryTestSuite) TestCustomClassifier() {
+	var attempts int
+
+	r.sut.Retryable = func(err error) bool { return err.Error() == "bacon" }
+	err := r.sut.do(r.ctx, func() error {
+		attempts++
+		return errors.New("bacon")
+	})
+
+	r.EqualError(err, "bacon")
+	r.Equal(3, attempts)
+}
+
+func (r *retryTestSuite) TestZeroValueDisablesRetries() {
+	var attempts int
+
+	err := RetryPolicy{}.do(r.ctx, func() error {
+		attempts++
+		return awserr.New("ThrottlingException", "slow down", nil)
+	})
+
+	r.Error(err)
+	r.Equal(1, attempts)
+}
+
+func (r *retryTestSuite) TestDelay() {
+	r.sut.MaxDelay = 4 * time.Millisecond
+
+	r.Equal(time.Millisecond, r.sut.delay(1))
+	r.Equal(2*time.Millisecond, r.sut.delay(2))
+	r.Equal(4*time.Millisecond, r.sut.delay(3))
+	r.Equal(4*time.Millisecond, r.sut.delay(30))
+
+	r.sut.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := r.sut.delay(3)
+		r.True(delay >= 2*time.Millisecond && delay <= 4*time.Millisecond, delay)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	suite.Run(t, new(retryTestSuite))
+}
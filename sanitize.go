@@ -0,0 +1,88 @@
+package cloudwatch
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Base64Prefix starts messages which were base64-encoded by the writer because
+// they were not valid UTF-8.
+const Base64Prefix = "base64:"
+
+// ReplaceInvalidUTF8 makes the writer replace each run of bytes which are not
+// valid UTF-8 with the Unicode replacement character. This is the default.
+func ReplaceInvalidUTF8() CreateOption {
+	return func(w *writerImpl) {
+		w.sanitize = replaceInvalidUTF8
+	}
+}
+
+// EscapeInvalidUTF8 makes the writer replace each byte which is not valid
+// UTF-8 with its \xNN escape sequence.
+func EscapeInvalidUTF8() CreateOption {
+	return func(w *writerImpl) {
+		w.sanitize = func(message []byte) ([]byte, bool) {
+			return escapeInvalidUTF8(message), true
+		}
+	}
+}
+
+// Base64InvalidUTF8 makes the writer base64-encode whole messages which are not
+// valid UTF-8, prefixing them with Base64Prefix.
+func Base64InvalidUTF8() CreateOption {
+	return func(w *writerImpl) {
+		w.sanitize = func(message []byte) ([]byte, bool) {
+			return []byte(Base64Prefix + base64.StdEncoding.EncodeToString(message)), true
+		}
+	}
+}
+
+// DropInvalidUTF8 makes the writer drop messages which are not valid UTF-8,
+// passing them to callback first if it's not nil.
+func DropInvalidUTF8(callback func(message []byte)) CreateOption {
+	return func(w *writerImpl) {
+		w.sanitize = func(message []byte) ([]byte, bool) {
+			if callback != nil {
+				callback(message)
+			}
+			return nil, false
+		}
+	}
+}
+
+// sanitizeMessage applies the writer's policy to messages which are not valid
+// UTF-8, since a single such message would cause the whole batch to be
+// rejected. It returns false if the message should be dropped.
+func (w *writerImpl) sanitizeMessage(message []byte) ([]byte, bool) {
+	if utf8.Valid(message) {
+		return message, true
+	}
+
+	if w.sanitize == nil {
+		return replaceInvalidUTF8(message)
+	}
+
+	return w.sanitize(message)
+}
+
+func replaceInvalidUTF8(message []byte) ([]byte, bool) {
+	return []byte(strings.ToValidUTF8(string(message), string(utf8.RuneError))), true
+}
+
+func escapeInvalidUTF8(message []byte) []byte {
+	var ret strings.Builder
+
+	for len(message) > 0 {
+		r, size := utf8.DecodeRune(message)
+		if r == utf8.RuneError && size == 1 {
+			fmt.Fprintf(&ret, `\x%02x`, message[0])
+		} else {
+			ret.Write(message[:size])
+		}
+		message = message[size:]
+	}
+
+	return []byte(ret.String())
+}
@@ -0,0 +1,324 @@
+package cloudwatch
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/pkg/errors"
+)
+
+// WithSpool makes the writer keep a write-ahead log of buffered events in a
+// file in dir, so that events which were not sent when the process crashed,
+// or when the writer was shut down, are sent by the next writer created for
+// the same stream with the same dir. Events older than 14 days are not
+// replayed, since CloudWatch Logs would reject them anyway.
+//
+// Events are written to the spool without syncing it to disk, so they survive
+// the process crashing but not necessarily the host. The spool of a stream
+// must not be used by more than one writer at a time.
+//
+// If writing to the spool fails, Write returns the error, but the events it
+// was called with are still buffered in memory and sent, so the data must not
+// be written again. Subsequent calls to Write fail without buffering anything.
+func WithSpool(dir string) CreateOption {
+	return func(w *writerImpl) {
+		w.spoolDir = dir
+	}
+}
+
+// The spool file is rewritten with just the unacknowledged events once it
+// holds at least spoolCompactRecords records, most of them no longer needed.
+const spoolCompactRecords = 10000
+
+// spoolRecord is a single line of a spool file, holding either an event or
+// the acknowledgement of one.
+type spoolRecord struct {
+	ID        int64  `json:"id"`
+	Message   string `json:"message,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Ack       bool   `json:"ack,omitempty"`
+}
+
+// spool is an append-only file of buffered events and acknowledgements of
+// events which were sent. A nil spool does nothing.
+type spool struct {
+	file    *os.File
+	path    string
+	lastID  int64
+	ids     map[*cloudwatchlogs.InputLogEvent]int64 // Unacknowledged events.
+	records int                                     // Records in the file.
+	err     error
+
+	sync.Mutex
+}
+
+func (w *writerImpl) openSpool() error {
+	if w.spoolDir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(w.spoolDir, 0755); err != nil {
+		return errors.Wrap(err, "could not create the spool directory")
+	}
+
+	name := url.PathEscape(*w.groupName) + "#" + url.PathEscape(*w.streamName) + ".spool"
+
+	spool, events, err := openSpool(filepath.Join(w.spoolDir, name), w.now().Add(-maxEventAge))
+	if err != nil {
+		return err
+	}
+
+	w.spool = spool
+	for _, event := range events {
+		w.events.add(event)
+	}
+	return nil
+}
+
+// closeSpool closes the spool, if any, and returns err, or the error closing
+// the spool if err is nil.
+func (w *writerImpl) closeSpool(err error) error {
+	if closeErr := w.spool.close(); err == nil {
+		return closeErr
+	}
+	return err
+}
+
+// openSpool returns the spool at path along with the unacknowledged events
+// in it which are not older than earliest. The file is rewritten to hold just
+// these events, so that it doesn't grow across restarts.
+func openSpool(path string, earliest time.Time) (*spool, []*cloudwatchlogs.InputLogEvent, error) {
+	pending, err := readSpool(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		ret     = &spool{path: path, ids: make(map[*cloudwatchlogs.InputLogEvent]int64)}
+		events  []*cloudwatchlogs.InputLogEvent
+		records []spoolRecord
+	)
+
+	for _, record := range pending {
+		if record.Timestamp < millisFromTime(earliest) {
+			continue
+		}
+
+		event := &cloudwatchlogs.InputLogEvent{
+			Message:   aws.String(record.Message),
+			Timestamp: aws.Int64(record.Timestamp),
+		}
+
+		ret.lastID++
+		ret.ids[event] = ret.lastID
+		record.ID = ret.lastID
+
+		events = append(events, event)
+		records = append(records, record)
+	}
+
+	if err := ret.replace(records); err != nil {
+		return nil, nil, err
+	}
+	return ret, events, nil
+}
+
+// replace atomically replaces the spool file with one holding just records,
+// and opens it for appending.
+func (s *spool) replace(records []spoolRecord) error {
+	if err := writeFileAtomically(s.path, encodeRecords(records)); err != nil {
+		return errors.Wrap(err, "could not replace the spool file")
+	}
+
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Wrap(err, "could not open the spool file")
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file = file
+	s.records = len(records)
+	return nil
+}
+
+// readSpool returns the unacknowledged events in the spool at path, in the
+// order they were written. A record torn by a crash ends the spool.
+func readSpool(path string) ([]spoolRecord, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "could not open the spool file")
+	}
+	defer file.Close()
+
+	var (
+		records []spoolRecord
+		acked   = make(map[int64]bool)
+		decoder = json.NewDecoder(file)
+	)
+
+	for {
+		var record spoolRecord
+		if err := decoder.Decode(&record); err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "could not decode the spool file")
+		}
+
+		if record.Ack {
+			acked[record.ID] = true
+		} else {
+			records = append(records, record)
+		}
+	}
+
+	ret := records[:0]
+	for _, record := range records {
+		if !acked[record.ID] {
+			ret = append(ret, record)
+		}
+	}
+	return ret, nil
+}
+
+// append writes the event to the spool. Once writing fails, the spool keeps
+// returning the error.
+func (s *spool) append(event *cloudwatchlogs.InputLogEvent) error {
+	if s == nil {
+		return nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.lastID++
+	if s.err = s.write(spoolRecord{
+		ID:        s.lastID,
+		Message:   aws.StringValue(event.Message),
+		Timestamp: aws.Int64Value(event.Timestamp),
+	}); s.err != nil {
+		return s.err
+	}
+
+	s.ids[event] = s.lastID
+	return nil
+}
+
+// ack records that the events were sent, or don't need to be. Once there are
+// no unacknowledged events left, the spool file is emptied, and once most of
+// its records are no longer needed, it's compacted.
+func (s *spool) ack(events ...*cloudwatchlogs.InputLogEvent) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.err != nil {
+		return
+	}
+
+	var records []spoolRecord
+	for _, event := range events {
+		if id, ok := s.ids[event]; ok {
+			delete(s.ids, event)
+			records = append(records, spoolRecord{ID: id, Ack: true})
+		}
+	}
+
+	switch {
+	case len(s.ids) == 0:
+		s.err = errors.Wrap(s.file.Truncate(0), "could not truncate the spool file")
+		s.records = 0
+	case s.records+len(records) >= spoolCompactRecords && s.records+len(records) > 2*len(s.ids):
+		s.err = s.compact()
+	default:
+		s.err = s.write(records...)
+	}
+}
+
+// compact rewrites the spool file with just the unacknowledged events, in the
+// order they were written.
+func (s *spool) compact() error {
+	records := make([]spoolRecord, 0, len(s.ids))
+	for event, id := range s.ids {
+		records = append(records, spoolRecord{
+			ID:        id,
+			Message:   aws.StringValue(event.Message),
+			Timestamp: aws.Int64Value(event.Timestamp),
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+
+	return s.replace(records)
+}
+
+func (s *spool) write(records ...spoolRecord) error {
+	if _, err := s.file.Write(encodeRecords(records)); err != nil {
+		return errors.Wrap(err, "could not write to the spool")
+	}
+
+	s.records += len(records)
+	return nil
+}
+
+func encodeRecords(records []spoolRecord) []byte {
+	var ret []byte
+	for _, record := range records {
+		// Encoding a struct of strings and numbers can't fail.
+		line, _ := json.Marshal(record)
+		ret = append(append(ret, line...), '\n')
+	}
+	return ret
+}
+
+func (s *spool) error() error {
+	if s == nil {
+		return nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	return s.err
+}
+
+// close closes the spool file, removing it if there are no unacknowledged
+// events left.
+func (s *spool) close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	err := errors.Wrap(s.file.Close(), "could not close the spool file")
+	if len(s.ids) == 0 && s.err == nil && err == nil {
+		err = errors.Wrap(os.Remove(s.path), "could not remove the spool file")
+	}
+
+	if s.err != nil {
+		return s.err
+	}
+	return err
+}
@@ -0,0 +1,185 @@
+package cloudwatch
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/suite"
+)
+
+type spoolTestSuite struct {
+	suite.Suite
+
+	ctx                   context.Context
+	dir                   string
+	groupName, streamName string
+	api                   *MemoryAPI
+	sut                   Group
+}
+
+func (s *spoolTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.groupName = "group/name"
+	s.streamName = "streamName"
+
+	dir, err := ioutil.TempDir("", "spool")
+	s.Require().NoError(err)
+	s.dir = dir
+
+	s.api, s.sut = newMemoryGroup(s.T(), s.groupName)
+}
+
+func (s *spoolTestSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *spoolTestSuite) TestReplaysUnsentEvents() {
+	ctx, cancel := context.WithCancel(s.ctx)
+
+	first, err := s.sut.Create(ctx, s.streamName, WithSpool(s.dir))
+	s.Require().NoError(err)
+
+	// Nothing can be sent once the context is cancelled.
+	cancel()
+	_, err = io.WriteString(first, "Hello\nWorld\n")
+	s.Require().NoError(err)
+
+	done, cancel := context.WithCancel(s.ctx)
+	cancel()
+	s.IsType(new(ShutdownError), first.Shutdown(done))
+	s.Empty(s.messages())
+
+	second, err := s.sut.Create(s.ctx, s.streamName, WithSpool(s.dir))
+	s.Require().NoError(err)
+	s.Require().NoError(second.Close())
+
+	s.Equal([]string{"Hello\n", "World\n"}, s.messages())
+	s.Empty(s.files())
+}
+
+func (s *spoolTestSuite) TestRemovesSpoolOnceSent() {
+	writer, err := s.sut.Create(s.ctx, s.streamName, WithSpool(s.dir))
+	s.Require().NoError(err)
+
+	_, err = io.WriteString(writer, "Hello\n")
+	s.Require().NoError(err)
+	s.Len(s.files(), 1)
+
+	s.Require().NoError(writer.Flush())
+	s.Require().NoError(writer.Close())
+
+	s.Equal([]string{"Hello\n"}, s.messages())
+	s.Empty(s.files())
+}
+
+func (s *spoolTestSuite) TestCompactsWhileWriting() {
+	// Events keep being written while batches are sent, so there are always
+	// some left to send.
+	group := NewGroup(slowAPI{s.api}, s.groupName)
+	writer, err := group.Create(s.ctx, s.streamName, WithSpool(s.dir))
+	s.Require().NoError(err)
+
+	var maxSize int64
+	for start := time.Now(); time.Since(start) < 2*time.Second; {
+		_, err = io.WriteString(writer, strings.Repeat("hello world\n", 100))
+		s.Require().NoError(err)
+		time.Sleep(5 * time.Millisecond)
+
+		info, err := os.Stat(filepath.Join(s.dir, s.files()[0]))
+		s.Require().NoError(err)
+		if info.Size() > maxSize {
+			maxSize = info.Size()
+		}
+	}
+
+	s.Require().NoError(writer.Close())
+	s.Empty(s.files())
+
+	// Without compaction, the file would hold every event written and as
+	// many acknowledgements.
+	s.Less(maxSize, int64(spoolCompactRecords*100))
+}
+
+func (s *spoolTestSuite) TestFailsOnceSpoolingFails() {
+	writer, err := s.sut.Create(s.ctx, s.streamName, WithSpool(s.dir))
+	s.Require().NoError(err)
+
+	s.Require().NoError(writer.(*writerImpl).spool.file.Close())
+
+	// The data is buffered all the same, so it mustn't be written again.
+	n, err := io.WriteString(writer, "Hello\n")
+	s.Error(err)
+	s.Equal(6, n)
+
+	n, err = io.WriteString(writer, "World\n")
+	s.Error(err)
+	s.Zero(n)
+
+	s.Error(writer.Close())
+	s.Equal([]string{"Hello\n"}, s.messages())
+}
+
+func (s *spoolTestSuite) TestSkipsAcknowledgedAndTooOldEvents() {
+	path := filepath.Join(s.dir, "spool")
+	now := strconv.FormatInt(millisFromTime(time.Now()), 10)
+
+	s.Require().NoError(ioutil.WriteFile(path, []byte(
+		`{"id":1,"message":"sent","timestamp":`+now+"}\n"+
+			`{"id":2,"message":"old","timestamp":1000}`+"\n"+
+			`{"id":3,"message":"pending","timestamp":`+now+"}\n"+
+			`{"id":1,"ack":true}`+"\n"+
+			`{"id":4,"message":"torn`,
+	), 0644))
+
+	sut, events, err := openSpool(path, time.Now().Add(-maxEventAge))
+	s.Require().NoError(err)
+	defer sut.close()
+
+	s.Require().Len(events, 1)
+	s.Equal("pending", *events[0].Message)
+
+	// The file only holds the pending event now.
+	records, err := readSpool(path)
+	s.Require().NoError(err)
+	s.Require().Len(records, 1)
+	s.Equal("pending", records[0].Message)
+}
+
+func (s *spoolTestSuite) messages() []string {
+	return storedMessages(s.T(), s.api, s.groupName, s.streamName)
+}
+
+func (s *spoolTestSuite) files() []string {
+	infos, err := ioutil.ReadDir(s.dir)
+	s.Require().NoError(err)
+
+	var ret []string
+	for _, info := range infos {
+		ret = append(ret, info.Name())
+	}
+	return ret
+}
+
+// slowAPI adds latency to PutLogEvents requests.
+type slowAPI struct {
+	*MemoryAPI
+}
+
+func (s slowAPI) PutLogEventsWithContext(ctx aws.Context, input *cloudwatchlogs.PutLogEventsInput, opts ...request.Option) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	time.Sleep(50 * time.Millisecond)
+	return s.MemoryAPI.PutLogEventsWithContext(ctx, input, opts...)
+}
+
+func TestSpool(t *testing.T) {
+	suite.Run(t, new(spoolTestSuite))
+}
@@ -0,0 +1,95 @@
+package cloudwatch
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+// clampMargin keeps clamped timestamps safely within the accepted range, which
+// keeps moving until the events reach the service.
+const clampMargin = time.Minute
+
+// DropOutOfRangeEvents makes the writer drop events which are older than 14
+// days or more than 2 hours in the future when they're about to be sent, since
+// CloudWatch Logs would reject them anyway. This is the default.
+func DropOutOfRangeEvents() CreateOption {
+	return func(w *writerImpl) {
+		w.outOfRange = nil
+	}
+}
+
+// ClampOutOfRangeEvents makes the writer move the timestamps of events which
+// are older than 14 days or more than 2 hours in the future into the range
+// accepted by CloudWatch Logs, rather than dropping them.
+func ClampOutOfRangeEvents() CreateOption {
+	return func(w *writerImpl) {
+		w.outOfRange = func(event *cloudwatchlogs.InputLogEvent, earliest, latest time.Time) bool {
+			if aws.Int64Value(event.Timestamp) < millisFromTime(earliest) {
+				event.Timestamp = aws.Int64(millisFromTime(earliest.Add(clampMargin)))
+			} else {
+				event.Timestamp = aws.Int64(millisFromTime(latest.Add(-clampMargin)))
+			}
+			return true
+		}
+	}
+}
+
+// RouteOutOfRangeEvents makes the writer pass events which are older than 14
+// days or more than 2 hours in the future to callback rather than sending
+// them to CloudWatch Logs.
+func RouteOutOfRangeEvents(callback func(*cloudwatchlogs.InputLogEvent)) CreateOption {
+	return func(w *writerImpl) {
+		w.outOfRange = func(event *cloudwatchlogs.InputLogEvent, earliest, latest time.Time) bool {
+			callback(event)
+			return false
+		}
+	}
+}
+
+// checkTimestamps applies the writer's policy to events which CloudWatch Logs
+// would reject because of their timestamps, and returns the events to send.
+func (w *writerImpl) checkTimestamps(events []*cloudwatchlogs.InputLogEvent) []*cloudwatchlogs.InputLogEvent {
+	var (
+		now      = w.now()
+		earliest = now.Add(-maxEventAge)
+		latest   = now.Add(maxEventFutureAge)
+		ret      = events[:0]
+		moved    bool
+	)
+
+	for _, event := range events {
+		timestamp := aws.Int64Value(event.Timestamp)
+
+		switch {
+		case timestamp < millisFromTime(earliest):
+			atomic.AddInt64(&w.stats.TooOld, 1)
+		case timestamp > millisFromTime(latest):
+			atomic.AddInt64(&w.stats.TooNew, 1)
+		default:
+			ret = append(ret, event)
+			continue
+		}
+
+		if w.outOfRange != nil && w.outOfRange(event, earliest, latest) {
+			ret = append(ret, event)
+			moved = true
+		} else {
+			w.spool.ack(event)
+		}
+	}
+
+	// The policy may have moved timestamps past those of events which were
+	// in range, and PutLogEvents requires the batch to be in chronological
+	// order.
+	if moved {
+		sort.SliceStable(ret, func(i, j int) bool {
+			return aws.Int64Value(ret[i].Timestamp) < aws.Int64Value(ret[j].Timestamp)
+		})
+	}
+
+	return ret
+}
@@ -1,11 +1,10 @@
 package cloudwatch
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -20,16 +19,87 @@ type writerImpl struct {
 
 	ctx context.Context
 
-	closed bool
+	closed int32 // Accessed atomically, non-zero once the writer is closed.
 	err    error
 
-	events  *eventsBuffer
-	nowFunc func() time.Time
-	onEvent func(*cloudwatchlogs.InputLogEvent)
+	events   *eventsBuffer
+	nowFunc  func() time.Time
+	onEvent  func(*cloudwatchlogs.InputLogEvent)
+	truncate bool // Truncate rather than split oversized messages.
+
+	// sanitize handles messages which are not valid UTF-8.
+	sanitize func(message []byte) ([]byte, bool)
+
+	// outOfRange handles events with timestamps CloudWatch Logs would reject,
+	// returning true if the event should be sent anyway.
+	outOfRange func(event *cloudwatchlogs.InputLogEvent, earliest, latest time.Time) bool
+
+	onRejected func(*RejectedLogEventsInfoError)
+	retries    RetryPolicy
+
+	// Without sequence tokens, batches are sent without one and the token
+	// returned by the service is ignored.
+	noSequenceTokens bool
+
+	// inFlight holds a slot for each batch being sent. Its capacity is the
+	// number of batches sent concurrently, which is only ever more than one
+	// without sequence tokens.
+	inFlight    chan struct{}
+	concurrency int
+
+	// The number of events in batches being sent, and in batches which
+	// failed. Both are accessed atomically.
+	sending, failed int64
+
+	// If set, buffered events are written to the spool until they're sent.
+	spoolDir string
+	spool    *spool
+
+	// Limits on the buffered events, and what to do once they're reached.
+	// Blocked calls to Write wait for space to be signalled.
+	maxBufferBytes, maxBufferEvents int
+	bufferPolicy                    bufferPolicy
+	space                           chan struct{}
+
+	// The number of InvalidSequenceTokenExceptions tolerated for a single
+	// batch. Zero means defaultSequenceConflicts.
+	sequenceConflicts int
+
+	stats WriterStats // Updated atomically.
+
+	// The framer splits written data into event messages. With line
+	// buffering, an unterminated frame is held in partial until it's
+	// terminated by a subsequent Write, it's older than maxLineAge, or the
+	// writer is flushed or closed.
+	framer        Framer
+	lineBuffering bool
+	maxLineAge    time.Duration
+	partial       []byte
+	partialSince  time.Time
+
+	// With multi-line grouping, lines for which continues returns true are
+	// appended to the group of lines held since groupSince, until a line
+	// which doesn't continue it arrives or no line arrives for groupTimeout.
+	continues    func(line []byte) bool
+	groupTimeout time.Duration
+	group        []byte
+	groupSince   time.Time
+	groupUpdated time.Time
+
+	// With flush triggers, the background goroutine sends events when a
+	// full batch is ready, the earliest batch was buffered maxLatency ago, or
+	// nothing was written since writtenAt for idleTimeout, and waits for
+	// written to be signalled otherwise.
+	maxLatency  time.Duration
+	idleTimeout time.Duration
+	writtenAt   time.Time
+	written     chan struct{}
+
+	pendingMu sync.Mutex // This protects partial lines, groups and writtenAt.
 
 	throttle <-chan time.Time
 
-	sync.Mutex // This protects calls to flush.
+	sync.Mutex // This protects err.
 }
 
 // WithInputCallback allows setting a function introspecting each input log
@@ -40,6 +110,35 @@ func WithInputCallback(callback func(*cloudwatchlogs.InputLogEvent)) CreateOptio
 	}
 }
 
+// WithMaxSequenceConflicts sets how many times sending a single batch is
+// retried after failing with InvalidSequenceTokenException, which happens
+// when multiple writers write to the same stream. Once exceeded, sending fails
+// with a *SequenceConflictError.
+func WithMaxSequenceConflicts(n int) CreateOption {
+	return func(w *writerImpl) {
+		w.sequenceConflicts = n
+	}
+}
+
+// WithRejectedEventsCallback allows setting a function to be called with the
+// events CloudWatch Logs rejected from a batch. The rest of the batch is
+// accepted, so the writer keeps going.
+func WithRejectedEventsCallback(callback func(*RejectedLogEventsInfoError)) CreateOption {
+	return func(w *writerImpl) {
+		w.onRejected = callback
+	}
+}
+
+// TruncateOversizedEvents makes the writer truncate messages too large for a
+// single CloudWatch Logs event, marking them with TruncationMarker. By default
+// such messages are split into multiple events, each but the last one ending
+// with ContinuationMarker.
+func TruncateOversizedEvents() CreateOption {
+	return func(w *writerImpl) {
+		w.truncate = true
+	}
+}
+
 // FromToken allows writing from an arbitrary sequence token.
 func FromToken(sequenceToken string) CreateOption {
 	return func(w *writerImpl) {
@@ -47,6 +146,47 @@ func FromToken(sequenceToken string) CreateOption {
 	}
 }
 
+// WithoutSequenceTokens makes the writer send batches without a sequence
+// token, which CloudWatch Logs no longer requires. This saves looking up the
+// token of an existing stream when the writer is created, and lets multiple
+// writers send to the same stream without conflicting. Older endpoints and
+// emulators which still require tokens will fail with
+// InvalidSequenceTokenException, which the writer then returns as is.
+func WithoutSequenceTokens() CreateOption {
+	return func(w *writerImpl) {
+		w.noSequenceTokens = true
+		w.sequenceToken = nil
+	}
+}
+
+// WithConcurrency sets the number of batches sent concurrently, up to n per
+// throttling interval, which raises the throughput of a single writer. It only
+// takes effect together with WithoutSequenceTokens, as batches must otherwise
+// be sent one after another.
+//
+// Events within a batch are always sent in order, but concurrent batches may
+// be stored in any order relative to each other, so readers get events in
+// timestamp order rather than the order in which they were written. The
+// rejected events callback may be called concurrently.
+func WithConcurrency(n int) CreateOption {
+	return func(w *writerImpl) {
+		w.concurrency = n
+	}
+}
+
+// WithLineBuffering makes the writer hold a line which isn't terminated by a
+// newline until it is terminated by a subsequent Write, so that lines written
+// in multiple chunks result in a single event. An unterminated line is sent on
+// its own once it's older than maxAge, or when the writer is flushed or closed.
+// A zero maxAge means no limit. With a custom Framer, the same applies to
+// incomplete frames.
+func WithLineBuffering(maxAge time.Duration) CreateOption {
+	return func(w *writerImpl) {
+		w.lineBuffering = true
+		w.maxLineAge = maxAge
+	}
+}
+
 func freezeTime(now time.Time) CreateOption {
 	return func(w *writerImpl) {
 		w.nowFunc = func() time.Time {
@@ -56,15 +196,21 @@ func freezeTime(now time.Time) CreateOption {
 }
 
 // Write takes the buffer, and creates a Cloudwatch Log event for each
-// individual line. If Flush returns an error, subsequent calls to Write will
-// fail.
+// individual line, or frame if a custom Framer is used. If Flush returns an
+// error, subsequent calls to Write will fail.
 func (w *writerImpl) Write(b []byte) (int, error) {
-	if w.closed {
+	if w.isClosed() {
 		return 0, io.ErrClosedPipe
 	}
 
-	if w.err != nil {
-		return 0, w.err
+	if err := w.error(); err != nil {
+		return 0, err
+	} else if err := w.spool.error(); err != nil {
+		return 0, err
+	}
+
+	if err := w.waitForSpace(len(b)); err != nil {
+		return 0, err
 	}
 
 	return w.buffer(b)
@@ -74,11 +220,21 @@ func (w *writerImpl) Write(b []byte) (int, error) {
 func (w *writerImpl) start() error {
 	for {
 		// Exit if the stream is closed.
-		if w.closed {
+		if w.isClosed() {
 			return nil
 		}
 
-		if err := w.flushTrottled(); err != nil {
+		w.flushPending(false)
+
+		if !w.flushDue() {
+			w.waitForData()
+			continue
+		}
+
+		if err := w.flushTrottled(); err != nil && !isRejection(err) {
+			// Sending may have stopped before any request failed, e.g.
+			// because the writer's context is done.
+			w.fail(err)
 			return err
 		}
 	}
@@ -87,13 +243,62 @@ func (w *writerImpl) start() error {
 // Close closes the writer. Any subsequent calls to Write will return
 // io.ErrClosedPipe.
 func (w *writerImpl) Close() error {
-	w.closed = true
+	atomic.StoreInt32(&w.closed, 1)
+	w.signalSpace()
+	w.flushPending(true)
+	w.dropPartial()
+
+	// Sending may stop before any request fails, e.g. because the writer's
+	// context is done.
+	var sendErr error
 	for w.events.hasMore() {
-		if w.flushTrottled() != nil {
+		if err := w.flushTrottled(); err != nil && !isRejection(err) {
+			sendErr = err
 			break
 		}
 	}
-	return w.err
+
+	w.wait(context.Background())
+	if err := w.error(); err != nil {
+		sendErr = err
+	}
+	return w.closeSpool(sendErr)
+}
+
+// Flush sends all buffered events to CloudWatch Logs, waiting for any batch
+// being sent in the background, and returns the first error encountered.
+// Rejected events don't stop the flush, but are reported once it's done.
+func (w *writerImpl) Flush() error {
+	if w.isClosed() {
+		return io.ErrClosedPipe
+	}
+
+	w.flushPending(true)
+
+	var rejection error
+	for w.events.hasMore() {
+		if err := w.flushTrottled(); err == nil {
+			continue
+		} else if !isRejection(err) {
+			return err
+		} else if rejection == nil {
+			rejection = err
+		}
+	}
+
+	// Batches may have been drained from the buffer by the background
+	// goroutine, but not sent yet.
+	w.wait(context.Background())
+
+	if err := w.error(); err != nil {
+		return err
+	}
+	return rejection
+}
+
+// Sync is an alias for Flush.
+func (w *writerImpl) Sync() error {
+	return w.Flush()
 }
 
 func (w *writerImpl) flushTrottled() error {
@@ -101,95 +306,392 @@ func (w *writerImpl) flushTrottled() error {
 	return w.flushBatch()
 }
 
+// Shutdown closes the writer, sending as many buffered events as possible
+// before ctx is done. Unlike Close, it uses ctx rather than the writer's
+// context for the requests, so it can be used after the latter is cancelled.
+// If some events could not be sent, a *ShutdownError is returned. Once ctx is
+// done, Shutdown returns without waiting for batches being sent in the
+// background, and counts their events as dropped.
+func (w *writerImpl) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&w.closed, 1)
+	w.signalSpace()
+	w.flushPending(true)
+	w.dropPartial()
+
+	for w.events.hasMore() {
+		select {
+		case <-ctx.Done():
+			return w.shutdownError(ctx.Err())
+		case <-w.throttle:
+		}
+
+		if err := w.flushBatches(ctx); err != nil && !isRejection(err) {
+			return w.shutdownError(err)
+		}
+	}
+
+	// Batches may have been drained from the buffer by the background
+	// goroutine, but not sent yet. The writer may have failed just because its
+	// context is done, which only matters if events were lost.
+	if err := w.wait(ctx); err != nil {
+		return w.shutdownError(err)
+	} else if atomic.LoadInt64(&w.failed) > 0 {
+		return w.shutdownError(w.error())
+	}
+	return w.closeSpool(nil)
+}
+
+// shutdownError closes the spool and returns a *ShutdownError counting the
+// events which are still buffered, in batches which failed, and in batches
+// still being sent.
+func (w *writerImpl) shutdownError(err error) error {
+	dropped := w.events.discard() + int(atomic.LoadInt64(&w.failed)+atomic.LoadInt64(&w.sending))
+	return w.closeSpool(&ShutdownError{Dropped: dropped, Err: err})
+}
+
 func (w *writerImpl) flushBatch() error {
-	w.Lock()
-	defer w.Unlock()
+	return w.flushBatches(w.ctx)
+}
 
-	events := w.events.drain()
+// flushBatches sends as many batches from the buffer as the writer may send
+// concurrently, and waits for them. It returns the first error encountered,
+// preferring errors other than rejected events. Once ctx is done, no more
+// batches are sent.
+func (w *writerImpl) flushBatches(ctx context.Context) error {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		first error
+	)
 
-	// No events to flush.
-	if len(events) == 0 {
+	for i := 0; i < cap(w.inFlight) && w.events.hasMore(); i++ {
+		if err := w.acquire(ctx); err != nil {
+			mu.Lock()
+			first = err
+			mu.Unlock()
+			break
+		}
+
+		events := w.checkTimestamps(w.events.drain())
+		w.signalSpace()
+
+		if len(events) == 0 {
+			<-w.inFlight
+			continue
+		}
+
+		wg.Add(1)
+		atomic.AddInt64(&w.sending, int64(len(events)))
+
+		go func() {
+			defer wg.Done()
+			err := w.send(ctx, events)
+
+			if err != nil && !isRejection(err) {
+				atomic.AddInt64(&w.failed, int64(len(events)))
+			}
+			atomic.AddInt64(&w.sending, -int64(len(events)))
+			<-w.inFlight
+
+			if err == nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if first == nil || isRejection(first) && !isRejection(err) {
+				first = err
+			}
+		}()
+	}
+
+	wg.Wait()
+	return first
+}
+
+// acquire takes a slot for sending a batch, unless ctx is done first.
+func (w *writerImpl) acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case w.inFlight <- struct{}{}:
 		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
+
+// send sends a single batch of events. Once it fails for a reason other than
+// rejected events, subsequent calls to Write fail as well.
+func (w *writerImpl) send(ctx context.Context, events []*cloudwatchlogs.InputLogEvent) error {
+	err := w.flush(ctx, events)
+
+	// Rejected events are reported, but the rest of the batch was accepted so
+	// the writer can keep going.
+	if err == nil || isRejection(err) {
+		w.spool.ack(events...)
+	}
+
+	if rejection, ok := err.(*RejectedLogEventsInfoError); ok {
+		atomic.AddInt64(&w.stats.Rejected, int64(rejection.Count()))
+		if w.onRejected != nil {
+			w.onRejected(rejection)
+		}
+		return rejection
+	}
+
+	if err != nil {
+		w.fail(err)
+	}
+	return err
+}
+
+// fail makes subsequent calls to Write fail with err, unless they already
+// fail with another error.
+func (w *writerImpl) fail(err error) {
+	w.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.Unlock()
+
+	// Blocked calls to Write should fail as well.
+	w.signalSpace()
+}
+
+// wait blocks until no batches are being sent, or ctx is done.
+func (w *writerImpl) wait(ctx context.Context) error {
+	acquired := 0
+	defer func() {
+		for ; acquired > 0; acquired-- {
+			<-w.inFlight
+		}
+	}()
+
+	for ; acquired < cap(w.inFlight); acquired++ {
+		if err := w.acquire(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *writerImpl) error() error {
+	w.Lock()
+	defer w.Unlock()
 
-	w.err = w.flush(events)
 	return w.err
 }
 
-// flush flushes a slice of log events. This method should be called
-// sequentially to ensure that the sequence token is updated properly.
-func (w *writerImpl) flush(events []*cloudwatchlogs.InputLogEvent) (err error) {
+// Stats returns counters describing the events handled by the writer.
+func (w *writerImpl) Stats() WriterStats {
+	return WriterStats{
+		TooOld:   atomic.LoadInt64(&w.stats.TooOld),
+		TooNew:   atomic.LoadInt64(&w.stats.TooNew),
+		Rejected: atomic.LoadInt64(&w.stats.Rejected),
+		Dropped:  atomic.LoadInt64(&w.stats.Dropped),
+		Unframed: atomic.LoadInt64(&w.stats.Unframed),
+	}
+}
+
+// flush flushes a slice of log events. Unless sequence tokens are disabled,
+// this method should be called sequentially to ensure that the sequence token
+// is updated properly.
+func (w *writerImpl) flush(ctx context.Context, events []*cloudwatchlogs.InputLogEvent) (err error) {
 	var resp *cloudwatchlogs.PutLogEventsOutput
 
-	for {
-		resp, err = w.client.PutLogEventsWithContext(w.ctx, &cloudwatchlogs.PutLogEventsInput{
-			LogEvents:     events,
-			LogGroupName:  w.groupName,
-			LogStreamName: w.streamName,
-			SequenceToken: w.sequenceToken,
+	for conflicts := 0; ; {
+		err = w.retries.do(ctx, func() (err error) {
+			resp, err = w.client.PutLogEventsWithContext(ctx, &cloudwatchlogs.PutLogEventsInput{
+				LogEvents:     events,
+				LogGroupName:  w.groupName,
+				LogStreamName: w.streamName,
+				SequenceToken: w.sequenceToken,
+			})
+			return err
 		})
 
 		if err == nil {
 			break
 		}
 
+		// The batch was sent before, most likely by a request which timed out
+		// on our side, so there's nothing left to do.
+		if accepted, ok := err.(*cloudwatchlogs.DataAlreadyAcceptedException); ok {
+			w.setSequenceToken(accepted.ExpectedSequenceToken)
+			return nil
+		}
+
 		sequenceError, ok := err.(*cloudwatchlogs.InvalidSequenceTokenException)
-		if !ok {
+		if !ok || w.noSequenceTokens {
 			return err
 		}
 
 		w.sequenceToken = sequenceError.ExpectedSequenceToken
+
+		// Another writer is most likely writing to the same stream. The first
+		// retry is immediate, subsequent ones back off to let it finish.
+		if conflicts++; conflicts > w.maxSequenceConflicts() {
+			return &SequenceConflictError{Conflicts: conflicts, Err: err}
+		} else if conflicts == 1 {
+			continue
+		}
+
+		timer := time.NewTimer(sequenceConflictBackoff.delay(conflicts - 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
 	}
 
+	w.setSequenceToken(resp.NextSequenceToken)
+
 	if resp.RejectedLogEventsInfo != nil {
-		w.err = &RejectedLogEventsInfoError{Info: resp.RejectedLogEventsInfo}
-		return w.err
+		return newRejectedLogEventsInfoError(resp.RejectedLogEventsInfo, events)
 	}
 
-	w.sequenceToken = resp.NextSequenceToken
-
 	return nil
 }
 
 // buffer splits up b into individual log events and inserts them into the
 // buffer.
 func (w *writerImpl) buffer(b []byte) (int, error) {
-	r := bufio.NewReader(bytes.NewReader(b))
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
 
-	var (
-		n   int
-		eof bool
-	)
+	if len(w.partial) == 0 {
+		w.partialSince = w.now()
+	}
+	w.partial = append(w.partial, b...)
 
-	for !eof {
-		b, err := r.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				eof = true
-			} else {
-				break
-			}
-		}
+	w.frame(!w.lineBuffering)
+	w.writtenAt = time.Now()
+	w.signalWritten()
 
-		if len(b) == 0 {
-			continue
-		}
+	return len(b), w.spool.error()
+}
 
-		event := &cloudwatchlogs.InputLogEvent{
-			Message:   aws.String(string(b)),
-			Timestamp: aws.Int64(w.now().UnixNano() / 1000000),
+// frame turns complete frames held in partial into events. If atEnd is set,
+// the framer is asked to frame the remaining data as well.
+func (w *writerImpl) frame(atEnd bool) {
+	framer := w.framer
+	if framer == nil {
+		framer = Newlines()
+	}
+
+	data := w.partial
+	for len(data) > 0 {
+		advance, message := framer.Frame(data, atEnd)
+		if advance == 0 {
+			break
 		}
 
-		if w.onEvent != nil {
-			w.onEvent(event)
+		if len(message) > 0 {
+			w.addLine(message, w.partialSince)
 		}
 
-		w.events.add(event)
+		data = data[advance:]
+		w.partialSince = w.now()
+	}
+
+	if len(data) == 0 {
+		w.partial = nil
+	} else if len(data) < len(w.partial) {
+		w.partial = append([]byte(nil), data...)
+	}
+}
+
+// flushPending turns the unterminated frame and the group of lines, if any,
+// into events if they're past their respective timeouts or force is set. Data
+// the framer refuses to frame is kept until more data completes the frame.
+func (w *writerImpl) flushPending(force bool) {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+
+	if len(w.partial) > 0 && (force || w.maxLineAge > 0 && w.now().Sub(w.partialSince) >= w.maxLineAge) {
+		w.frame(true)
+	}
+
+	if len(w.group) > 0 && (force || w.groupTimeout > 0 && w.now().Sub(w.groupUpdated) >= w.groupTimeout) {
+		w.flushGroup()
+	}
+}
+
+// dropPartial drops data the framer refused to frame when the writer is
+// closed, since no more data can complete the frame.
+func (w *writerImpl) dropPartial() {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+
+	if len(w.partial) > 0 {
+		atomic.AddInt64(&w.stats.Unframed, 1)
+		w.partial = nil
+	}
+}
 
-		n += len(b)
+func (w *writerImpl) addEvent(message []byte, timestamp time.Time) {
+	message, ok := w.sanitizeMessage(message)
+	if !ok {
+		return
 	}
 
-	return n, nil
+	event := &cloudwatchlogs.InputLogEvent{
+		Message:   aws.String(string(message)),
+		Timestamp: aws.Int64(timestamp.UnixNano() / 1000000),
+	}
+
+	if w.onEvent != nil {
+		w.onEvent(event)
+	}
+
+	if event.Message == nil || len(*event.Message) <= maxMessageSizeBytes {
+		w.enqueue(event)
+		return
+	}
+
+	if w.truncate {
+		event.Message = aws.String(truncateMessage(*event.Message))
+		w.enqueue(event)
+		return
+	}
+
+	for _, part := range splitMessage(*event.Message) {
+		w.enqueue(&cloudwatchlogs.InputLogEvent{
+			Message:   aws.String(part),
+			Timestamp: event.Timestamp,
+		})
+	}
+}
+
+func (w *writerImpl) setSequenceToken(token *string) {
+	if !w.noSequenceTokens {
+		w.sequenceToken = token
+	}
+}
+
+func (w *writerImpl) isClosed() bool {
+	return atomic.LoadInt32(&w.closed) != 0
+}
+
+// maxConcurrency returns the number of batches which may be sent
+// concurrently.
+func (w *writerImpl) maxConcurrency() int {
+	if !w.noSequenceTokens || w.concurrency < 1 {
+		return 1
+	}
+	return w.concurrency
+}
+
+func (w *writerImpl) maxSequenceConflicts() int {
+	if w.sequenceConflicts <= 0 {
+		return defaultSequenceConflicts
+	}
+	return w.sequenceConflicts
 }
 
 func (w *writerImpl) now() time.Time {
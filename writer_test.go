@@ -2,13 +2,20 @@ package cloudwatch
 
 import (
 	"context"
+	"errors"
 	"io"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -18,7 +25,7 @@ type writerTestSuite struct {
 	api                   *mockAPI
 	ctx                   context.Context
 	groupName, streamName string
-	sut                   io.WriteCloser
+	sut                   Writer
 }
 
 func (w *writerTestSuite) SetupTest() {
@@ -89,18 +96,35 @@ func (w *writerTestSuite) TestWriteRejected() {
 		[]request.Option(nil),
 	).Return(&cloudwatchlogs.PutLogEventsOutput{
 		RejectedLogEventsInfo: &cloudwatchlogs.RejectedLogEventsInfo{
-			TooOldLogEventEndIndex: aws.Int64(2),
+			TooOldLogEventEndIndex: aws.Int64(1),
 		},
+		NextSequenceToken: aws.String("cabbage"),
 	}, nil)
 
+	var rejected *RejectedLogEventsInfoError
+	WithRejectedEventsCallback(func(err *RejectedLogEventsInfoError) {
+		rejected = err
+	})(w.sut.(*writerImpl))
+
 	_, err := io.WriteString(w.sut, "Hello\nWorld")
 	w.NoError(err)
 
-	const expectedError = "log messages were rejected"
+	const expectedError = "1 log messages were rejected (1 too old, 0 too new, 0 expired)"
 	w.EqualError(w.sut.(*writerImpl).flushBatch(), expectedError)
 
-	_, err = io.WriteString(w.sut, "Hello")
-	w.EqualError(err, expectedError)
+	w.Require().NotNil(rejected)
+	w.Equal([]*cloudwatchlogs.InputLogEvent{
+		{Message: aws.String("Hello\n"), Timestamp: aws.Int64(1000)},
+	}, rejected.TooOld)
+	w.Empty(rejected.TooNew)
+	w.Empty(rejected.Expired)
+
+	w.Equal(int64(1), w.sut.Stats().Rejected)
+	w.Equal("cabbage", *w.sut.(*writerImpl).sequenceToken)
+
+	// The writer keeps going after a partial rejection.
+	_, err = io.WriteString(w.sut, "")
+	w.NoError(err)
 }
 
 func (w *writerTestSuite) TestWriteInvalidSequenceToken() {
@@ -144,6 +168,192 @@ func (w *writerTestSuite) TestWriteInvalidSequenceToken() {
 	w.Equal("cabbage", *w.sut.(*writerImpl).sequenceToken)
 }
 
+func (w *writerTestSuite) TestWriteRetriesByDefault() {
+	writer, err := NewGroup(w.api, w.groupName).Create(w.ctx, w.streamName)
+	w.Require().NoError(err)
+
+	w.api.On("PutLogEventsWithContext", w.ctx, mock.Anything, []request.Option(nil)).
+		Once().
+		Return((*cloudwatchlogs.PutLogEventsOutput)(nil), awserr.New("ThrottlingException", "slow down", nil))
+
+	w.api.On("PutLogEventsWithContext", w.ctx, mock.Anything, []request.Option(nil)).
+		Once().
+		Return(&cloudwatchlogs.PutLogEventsOutput{}, nil)
+
+	_, err = io.WriteString(writer, "Hello")
+	w.Require().NoError(err)
+
+	w.NoError(writer.Flush())
+	w.api.AssertNumberOfCalls(w.T(), "PutLogEventsWithContext", 2)
+	w.NoError(writer.Close())
+}
+
+func (w *writerTestSuite) TestWriteRetries() {
+	WithWriteRetries(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})(w.sut.(*writerImpl))
+
+	input := &cloudwatchlogs.PutLogEventsInput{
+		LogEvents: []*cloudwatchlogs.InputLogEvent{
+			{Message: aws.String("Hello"), Timestamp: aws.Int64(1000)},
+		},
+		LogGroupName:  aws.String(w.groupName),
+		LogStreamName: aws.String(w.streamName),
+	}
+
+	w.api.On("PutLogEventsWithContext", w.ctx, input, []request.Option(nil)).
+		Once().
+		Return((*cloudwatchlogs.PutLogEventsOutput)(nil), awserr.New("ThrottlingException", "slow down", nil))
+
+	w.api.On("PutLogEventsWithContext", w.ctx, input, []request.Option(nil)).
+		Once().
+		Return(&cloudwatchlogs.PutLogEventsOutput{}, nil)
+
+	_, err := io.WriteString(w.sut, "Hello")
+	w.Require().NoError(err)
+
+	w.NoError(w.sut.(*writerImpl).flushBatch())
+	w.api.AssertNumberOfCalls(w.T(), "PutLogEventsWithContext", 2)
+}
+
+func (w *writerTestSuite) TestWriteSequenceConflicts() {
+	WithMaxSequenceConflicts(2)(w.sut.(*writerImpl))
+
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		mock.Anything,
+		[]request.Option(nil),
+	).Return((*cloudwatchlogs.PutLogEventsOutput)(nil), &cloudwatchlogs.InvalidSequenceTokenException{
+		ExpectedSequenceToken: aws.String("bacon"),
+	})
+
+	_, err := io.WriteString(w.sut, "Hello")
+	w.Require().NoError(err)
+
+	err = w.sut.(*writerImpl).flushBatch()
+	w.Require().IsType(new(SequenceConflictError), err)
+	w.Equal(3, err.(*SequenceConflictError).Conflicts)
+	w.api.AssertNumberOfCalls(w.T(), "PutLogEventsWithContext", 3)
+}
+
+func (w *writerTestSuite) TestWriteDataAlreadyAccepted() {
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		&cloudwatchlogs.PutLogEventsInput{
+			LogEvents: []*cloudwatchlogs.InputLogEvent{
+				{Message: aws.String("Hello"), Timestamp: aws.Int64(1000)},
+			},
+			LogGroupName:  aws.String(w.groupName),
+			LogStreamName: aws.String(w.streamName),
+		},
+		[]request.Option(nil),
+	).Once().Return((*cloudwatchlogs.PutLogEventsOutput)(nil), &cloudwatchlogs.DataAlreadyAcceptedException{
+		ExpectedSequenceToken: aws.String("bacon"),
+	})
+
+	_, err := io.WriteString(w.sut, "Hello")
+	w.Require().NoError(err)
+
+	w.NoError(w.sut.(*writerImpl).flushBatch())
+	w.Equal("bacon", *w.sut.(*writerImpl).sequenceToken)
+}
+
+func (w *writerTestSuite) TestWriteWithoutSequenceTokens() {
+	WithoutSequenceTokens()(w.sut.(*writerImpl))
+
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		&cloudwatchlogs.PutLogEventsInput{
+			LogEvents: []*cloudwatchlogs.InputLogEvent{
+				{Message: aws.String("Hello"), Timestamp: aws.Int64(1000)},
+			},
+			LogGroupName:  aws.String(w.groupName),
+			LogStreamName: aws.String(w.streamName),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: aws.String("bacon")}, nil)
+
+	_, err := io.WriteString(w.sut, "Hello")
+	w.Require().NoError(err)
+
+	w.NoError(w.sut.(*writerImpl).flushBatch())
+	w.Nil(w.sut.(*writerImpl).sequenceToken)
+}
+
+func (w *writerTestSuite) TestWriteWithoutSequenceTokens_TokenRequired() {
+	WithoutSequenceTokens()(w.sut.(*writerImpl))
+
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		mock.Anything,
+		[]request.Option(nil),
+	).Once().Return((*cloudwatchlogs.PutLogEventsOutput)(nil), &cloudwatchlogs.InvalidSequenceTokenException{
+		ExpectedSequenceToken: aws.String("bacon"),
+	})
+
+	_, err := io.WriteString(w.sut, "Hello")
+	w.Require().NoError(err)
+
+	w.IsType(new(cloudwatchlogs.InvalidSequenceTokenException), w.sut.(*writerImpl).flushBatch())
+	w.Nil(w.sut.(*writerImpl).sequenceToken)
+}
+
+func (w *writerTestSuite) TestWriteConcurrently() {
+	// Without the background goroutine, both batches are sent by the same
+	// flush.
+	writer, err := NewGroup(w.api, w.groupName).(*groupImpl).create(
+		w.ctx,
+		w.streamName,
+		freezeTime(time.Unix(1, 0)),
+		WithoutSequenceTokens(),
+		WithConcurrency(2),
+	)
+	w.Require().NoError(err)
+
+	// Each call waits for the other one to start.
+	var (
+		started, overlapped int32
+		bothStarted         = make(chan struct{})
+	)
+
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		mock.Anything,
+		[]request.Option(nil),
+	).Run(func(mock.Arguments) {
+		if atomic.AddInt32(&started, 1) == 2 {
+			close(bothStarted)
+		}
+
+		select {
+		case <-bothStarted:
+			atomic.AddInt32(&overlapped, 1)
+		case <-time.After(5 * time.Second):
+		}
+	}).Return(&cloudwatchlogs.PutLogEventsOutput{}, nil)
+
+	_, err = io.WriteString(writer, strings.Repeat("Hello\n", maxBatchSizeEvents+1))
+	w.Require().NoError(err)
+
+	w.NoError(writer.flushBatch())
+	w.False(writer.events.hasMore())
+
+	w.api.AssertNumberOfCalls(w.T(), "PutLogEventsWithContext", 2)
+	w.EqualValues(2, atomic.LoadInt32(&overlapped))
+}
+
+func (w *writerTestSuite) TestConcurrencyRequiresNoSequenceTokens() {
+	writer := &writerImpl{}
+	WithConcurrency(4)(writer)
+	w.Equal(1, writer.maxConcurrency())
+
+	WithoutSequenceTokens()(writer)
+	w.Equal(4, writer.maxConcurrency())
+}
+
 func (w *writerTestSuite) TestNewline() {
 	w.api.On(
 		"PutLogEventsWithContext",
@@ -165,6 +375,359 @@ func (w *writerTestSuite) TestNewline() {
 	w.NoError(w.sut.Close())
 }
 
+func (w *writerTestSuite) TestFlush() {
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		&cloudwatchlogs.PutLogEventsInput{
+			LogEvents: []*cloudwatchlogs.InputLogEvent{
+				{Message: aws.String("Hello\n"), Timestamp: aws.Int64(1000)},
+			},
+			LogGroupName:  aws.String(w.groupName),
+			LogStreamName: aws.String(w.streamName),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.PutLogEventsOutput{}, nil)
+
+	_, err := io.WriteString(w.sut, "Hello\n")
+	w.Require().NoError(err)
+
+	w.NoError(w.sut.Flush())
+	w.False(w.sut.(*writerImpl).events.hasMore())
+	w.api.AssertNumberOfCalls(w.T(), "PutLogEventsWithContext", 1)
+
+	w.NoError(w.sut.Sync())
+	w.NoError(w.sut.Close())
+	w.Equal(io.ErrClosedPipe, w.sut.Flush())
+}
+
+func (w *writerTestSuite) TestShutdown() {
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		&cloudwatchlogs.PutLogEventsInput{
+			LogEvents: []*cloudwatchlogs.InputLogEvent{
+				{Message: aws.String("Hello\n"), Timestamp: aws.Int64(1000)},
+			},
+			LogGroupName:  aws.String(w.groupName),
+			LogStreamName: aws.String(w.streamName),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.PutLogEventsOutput{}, nil)
+
+	_, err := io.WriteString(w.sut, "Hello\n")
+	w.Require().NoError(err)
+
+	w.NoError(w.sut.Shutdown(w.ctx))
+
+	_, err = io.WriteString(w.sut, "Hello\n")
+	w.Equal(io.ErrClosedPipe, err)
+}
+
+func (w *writerTestSuite) TestShutdownDeadlineExceeded() {
+	_, err := io.WriteString(w.sut, "Hello\nWorld")
+	w.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	cancel()
+
+	err = w.sut.Shutdown(ctx)
+	w.Require().IsType(new(ShutdownError), err)
+	w.Equal(2, err.(*ShutdownError).Dropped)
+	w.EqualError(err, "2 log events were dropped: context canceled")
+
+	w.api.AssertNotCalled(w.T(), "PutLogEventsWithContext")
+}
+
+func (w *writerTestSuite) TestShutdownDoesNotWaitPastDeadline() {
+	release := make(chan struct{})
+	defer close(release)
+
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		mock.Anything,
+		[]request.Option(nil),
+	).Once().Run(func(mock.Arguments) { <-release }).Return(&cloudwatchlogs.PutLogEventsOutput{}, nil)
+
+	_, err := io.WriteString(w.sut, "Hello\n")
+	w.Require().NoError(err)
+
+	// Wait for the background goroutine to start sending the batch.
+	w.Eventually(func() bool {
+		return atomic.LoadInt64(&w.sut.(*writerImpl).sending) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(w.ctx, 50*time.Millisecond)
+	defer cancel()
+
+	err = w.sut.Shutdown(ctx)
+	w.Require().IsType(new(ShutdownError), err)
+	w.Equal(1, err.(*ShutdownError).Dropped)
+	w.Equal(context.DeadlineExceeded, err.(*ShutdownError).Err)
+}
+
+func (w *writerTestSuite) TestShutdownCountsFailedBatches() {
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		mock.Anything,
+		[]request.Option(nil),
+	).Once().Return((*cloudwatchlogs.PutLogEventsOutput)(nil), errors.New("bacon"))
+
+	_, err := io.WriteString(w.sut, "Hello\n")
+	w.Require().NoError(err)
+
+	w.Eventually(func() bool { return w.sut.(*writerImpl).error() != nil }, time.Second, time.Millisecond)
+
+	err = w.sut.Shutdown(w.ctx)
+	w.Require().IsType(new(ShutdownError), err)
+	w.EqualError(err, "1 log events were dropped: bacon")
+}
+
+func (w *writerTestSuite) TestCloseAfterContextCancelled() {
+	api, group := newMemoryGroup(w.T(), w.groupName)
+	ctx, cancel := context.WithCancel(w.ctx)
+
+	// Without the background goroutine, nothing is sent before Close.
+	writer, err := group.(*groupImpl).create(ctx, w.streamName)
+	w.Require().NoError(err)
+
+	_, err = io.WriteString(writer, "Hello\n")
+	w.Require().NoError(err)
+
+	cancel()
+	w.Equal(context.Canceled, writer.Close())
+	w.Empty(storedMessages(w.T(), api, w.groupName, w.streamName))
+}
+
+func (w *writerTestSuite) TestShutdownAfterContextCancelled() {
+	api, group := newMemoryGroup(w.T(), w.groupName)
+	ctx, cancel := context.WithCancel(w.ctx)
+
+	writer, err := group.(*groupImpl).create(ctx, w.streamName)
+	w.Require().NoError(err)
+
+	_, err = io.WriteString(writer, "Hello\nWorld\n")
+	w.Require().NoError(err)
+
+	// The background goroutine stops once the writer's context is done.
+	cancel()
+	w.Equal(context.Canceled, writer.start())
+
+	w.NoError(writer.Shutdown(w.ctx))
+	w.Equal([]string{"Hello\n", "World\n"}, storedMessages(w.T(), api, w.groupName, w.streamName))
+}
+
+func (w *writerTestSuite) TestLineBuffering() {
+	WithLineBuffering(0)(w.sut.(*writerImpl))
+
+	w.api.On(
+		"PutLogEventsWithContext",
+		w.ctx,
+		&cloudwatchlogs.PutLogEventsInput{
+			LogEvents: []*cloudwatchlogs.InputLogEvent{
+				{Message: aws.String("Hello World\n"), Timestamp: aws.Int64(1000)},
+				{Message: aws.String("Bacon"), Timestamp: aws.Int64(1000)},
+			},
+			LogGroupName:  aws.String(w.groupName),
+			LogStreamName: aws.String(w.streamName),
+		},
+		[]request.Option(nil),
+	).Once().Return(&cloudwatchlogs.PutLogEventsOutput{}, nil)
+
+	for _, chunk := range []string{"Hel", "lo ", "World\nBa", "con"} {
+		n, err := io.WriteString(w.sut, chunk)
+		w.Require().NoError(err)
+		w.Equal(len(chunk), n)
+	}
+
+	w.Equal("Bacon", string(w.sut.(*writerImpl).partial))
+	w.NoError(w.sut.Close())
+}
+
+func (w *writerTestSuite) TestLineBufferingMaxAge() {
+	writer := w.sut.(*writerImpl)
+	WithLineBuffering(time.Second)(writer)
+
+	_, err := io.WriteString(w.sut, "Hello")
+	w.Require().NoError(err)
+
+	writer.flushPending(false)
+	w.False(writer.events.hasMore())
+
+	writer.nowFunc = func() time.Time { return time.Unix(2, 0) }
+	writer.flushPending(false)
+	w.Equal([]*cloudwatchlogs.InputLogEvent{
+		{Message: aws.String("Hello"), Timestamp: aws.Int64(1000)},
+	}, writer.events.drain())
+}
+
+func (w *writerTestSuite) TestContinuationLines() {
+	writer := w.sut.(*writerImpl)
+	WithContinuationLines(IsIndented, time.Second)(writer)
+
+	_, err := io.WriteString(w.sut, "Exception\n\tat Foo\n  at Bar\nHello\n")
+	w.Require().NoError(err)
+
+	writer.flushPending(false)
+	w.Equal([]*cloudwatchlogs.InputLogEvent{
+		{Message: aws.String("Exception\n\tat Foo\n  at Bar\n"), Timestamp: aws.Int64(1000)},
+	}, writer.events.drain())
+
+	writer.nowFunc = func() time.Time { return time.Unix(2, 0) }
+	writer.flushPending(false)
+	w.Equal([]*cloudwatchlogs.InputLogEvent{
+		{Message: aws.String("Hello\n"), Timestamp: aws.Int64(1000)},
+	}, writer.events.drain())
+}
+
+func (w *writerTestSuite) TestEventStartPattern() {
+	writer := w.sut.(*writerImpl)
+	WithEventStartPattern(regexp.MustCompile(`^\d{4}-`), 0)(writer)
+
+	_, err := io.WriteString(w.sut, "2020-01-01 panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n2020-01-02 ok\n")
+	w.Require().NoError(err)
+
+	writer.flushPending(true)
+	w.Equal([]*cloudwatchlogs.InputLogEvent{
+		{Message: aws.String("2020-01-01 panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n"), Timestamp: aws.Int64(1000)},
+		{Message: aws.String("2020-01-02 ok\n"), Timestamp: aws.Int64(1000)},
+	}, writer.events.drain())
+}
+
+func (w *writerTestSuite) TestSplitOversizedEvents() {
+	writer := &writerImpl{events: newEventsBuffer()}
+	message := strings.Repeat("zażółć", maxEventSizeBytes/5)
+
+	_, err := io.WriteString(writer, message)
+	w.Require().NoError(err)
+
+	var (
+		parts       = writer.events.drain()
+		reassembled string
+	)
+	w.Require().Len(parts, 3)
+
+	for i, part := range parts {
+		w.LessOrEqual(len(*part.Message), maxMessageSizeBytes)
+		w.True(utf8.ValidString(*part.Message))
+
+		if i < len(parts)-1 {
+			w.True(strings.HasSuffix(*part.Message, ContinuationMarker))
+		}
+		reassembled += strings.TrimSuffix(*part.Message, ContinuationMarker)
+	}
+
+	w.Equal(message, reassembled)
+}
+
+func (w *writerTestSuite) TestTruncateOversizedEvents() {
+	writer := &writerImpl{events: newEventsBuffer()}
+	TruncateOversizedEvents()(writer)
+
+	_, err := io.WriteString(writer, strings.Repeat("zażółć", maxEventSizeBytes/5))
+	w.Require().NoError(err)
+
+	events := writer.events.drain()
+	w.Require().Len(events, 1)
+	w.LessOrEqual(len(*events[0].Message), maxMessageSizeBytes)
+	w.True(utf8.ValidString(*events[0].Message))
+	w.True(strings.HasSuffix(*events[0].Message, TruncationMarker))
+}
+
+func (w *writerTestSuite) TestInvalidUTF8() {
+	const input = "a\xffb\xfe\xfdc"
+
+	var dropped []string
+
+	for _, tc := range []struct {
+		option   CreateOption
+		expected []string
+	}{
+		{nil, []string{"a\uFFFDb\uFFFDc"}},
+		{EscapeInvalidUTF8(), []string{`a\xffb\xfe\xfdc`}},
+		{Base64InvalidUTF8(), []string{"base64:Yf9i/v1j"}},
+		{DropInvalidUTF8(func(message []byte) { dropped = append(dropped, string(message)) }), nil},
+	} {
+		writer := &writerImpl{events: newEventsBuffer()}
+		if tc.option != nil {
+			tc.option(writer)
+		}
+
+		_, err := io.WriteString(writer, input)
+		w.Require().NoError(err)
+
+		var messages []string
+		for _, event := range writer.events.drain() {
+			messages = append(messages, *event.Message)
+		}
+		w.Equal(tc.expected, messages)
+	}
+
+	w.Equal([]string{input}, dropped)
+}
+
+func (w *writerTestSuite) TestOutOfRangeEvents() {
+	var (
+		now    = time.Unix(100*24*3600, 0)
+		routed []string
+	)
+
+	for _, tc := range []struct {
+		option   CreateOption
+		expected []int64
+	}{
+		{nil, []int64{millisFromTime(now)}},
+		{ClampOutOfRangeEvents(), []int64{
+			millisFromTime(now.Add(-maxEventAge + clampMargin)),
+			millisFromTime(now),
+			millisFromTime(now.Add(maxEventFutureAge - clampMargin)),
+		}},
+		{RouteOutOfRangeEvents(func(event *cloudwatchlogs.InputLogEvent) {
+			routed = append(routed, *event.Message)
+		}), []int64{millisFromTime(now)}},
+	} {
+		writer := &writerImpl{events: newEventsBuffer(), nowFunc: func() time.Time { return now }}
+		if tc.option != nil {
+			tc.option(writer)
+		}
+
+		var timestamps []int64
+		for _, event := range writer.checkTimestamps([]*cloudwatchlogs.InputLogEvent{
+			{Message: aws.String("old"), Timestamp: aws.Int64(millisFromTime(now.Add(-maxEventAge - time.Second)))},
+			{Message: aws.String("ok"), Timestamp: aws.Int64(millisFromTime(now))},
+			{Message: aws.String("new"), Timestamp: aws.Int64(millisFromTime(now.Add(maxEventFutureAge + time.Second)))},
+		}) {
+			timestamps = append(timestamps, *event.Timestamp)
+		}
+
+		w.Equal(tc.expected, timestamps)
+		w.Equal(WriterStats{TooOld: 1, TooNew: 1}, writer.Stats())
+	}
+
+	w.Equal([]string{"old", "new"}, routed)
+}
+
+func (w *writerTestSuite) TestClampedEventsStayInOrder() {
+	now := time.Unix(100*24*3600, 0)
+
+	writer := &writerImpl{events: newEventsBuffer(), nowFunc: func() time.Time { return now }}
+	ClampOutOfRangeEvents()(writer)
+
+	var messages []string
+	for _, event := range writer.checkTimestamps([]*cloudwatchlogs.InputLogEvent{
+		{Message: aws.String("old"), Timestamp: aws.Int64(millisFromTime(now.Add(-maxEventAge - time.Second)))},
+		{Message: aws.String("oldest ok"), Timestamp: aws.Int64(millisFromTime(now.Add(-maxEventAge + 10*time.Second)))},
+		{Message: aws.String("latest ok"), Timestamp: aws.Int64(millisFromTime(now.Add(maxEventFutureAge - 10*time.Second)))},
+		{Message: aws.String("new"), Timestamp: aws.Int64(millisFromTime(now.Add(maxEventFutureAge + time.Second)))},
+	}) {
+		messages = append(messages, *event.Message)
+	}
+
+	w.Equal([]string{"oldest ok", "old", "new", "latest ok"}, messages)
+}
+
 func TestWriter(t *testing.T) {
 	suite.Run(t, new(writerTestSuite))
 }